@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(args []string) int
+}
+
+func commands() []command {
+	return []command{
+		{name: "run", description: "watch the input directory and process incoming files (default)", run: runProcessor},
+		{name: "dry-run", description: "check a file (--file PATH): print its summary, validation report and email without saving or sending", run: runDryRun},
+		{name: "export", description: "write an account's transactions (--account EMAIL --format csv|json|ndjson --output FILE)", run: runExport},
+		{name: "export-camt053", description: "write an account's month (--account EMAIL --period YYYY-MM) as an ISO 20022 camt.053 statement", run: runExportCamt053},
+		{name: "export-statement", description: "write an account's months (--account EMAIL --from YYYY-MM --to YYYY-MM) as a PDF statement", run: runExportStatement},
+		{name: "send-statements", description: "send the monthly statement of a period (--period YYYY-MM) to every account", run: runSendStatements},
+		{name: "preview", description: "serve the email rendered against fixture summaries and --file inputs, reloading on template changes", run: runPreview},
+		{name: "runs", description: "list the latest processing runs (--limit N)", run: runListRuns},
+		{name: "resend", description: "send the summary of a past processing run again (--run ID)", run: runResend},
+		{name: "recompute", description: "summarize an account's stored transactions and send it (--account EMAIL --from YYYY-MM --to YYYY-MM)", run: runRecompute},
+		{name: "reprocess", description: "process a quarantined file again (--run ID or --file PATH)", run: runReprocess},
+		{name: "audit", description: "list the latest administrative actions (--limit N)", run: runListAudit},
+		{name: "validate-templates", description: "render the email templates against sample summaries", run: runValidateTemplates},
+	}
+}
+
+func runCommand(name string, args []string) int {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd.run(args)
+		}
+	}
+
+	switch name {
+	case "help", "-h", "--help":
+		printUsage()
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	printUsage()
+	return 2
+}
+
+func printUsage() {
+	var b strings.Builder
+	b.WriteString("Usage: processor <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands() {
+		fmt.Fprintf(&b, "  %-20s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprint(os.Stderr, b.String())
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/NahuelDT/stori-challenge/internal/config"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/database"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/email"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/file"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+// runDryRun reads, validates and summarizes a file and renders its email without saving or
+// sending anything. The result goes to stdout as JSON, or to a directory as one summary,
+// report and HTML file per unit. It exits with 1 when the file is not valid
+func runDryRun(args []string) int {
+	flags := flag.NewFlagSet("dry-run", flag.ContinueOnError)
+	path := flags.String("file", "", "transaction file to check")
+	recipient := flags.String("recipient", "", "recipient whose email is rendered (default: RECIPIENT_EMAIL)")
+	output := flags.String("output", "", "directory for the results (default: JSON on stdout)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "--file is required")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+	if *recipient == "" {
+		*recipient = getRecipientEmail(cfg)
+	}
+	// Keep stdout for the result
+	logger := newLogger(os.Stderr, cfg.Server.LogLevel)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	templates, err := email.NewTemplateStore(cfg.Email.TemplateDirectory, logger)
+	if err != nil {
+		logger.Error("failed to load email templates", "error", err)
+		return 1
+	}
+	// The database is only read, for the account history shown in the email
+	var dataStore services.DataStore
+	if cfg.DatabaseEnabled() {
+		store, err := database.NewPostgresDataStore(cfg.Database, logger)
+		if err != nil {
+			logger.Warn("database unavailable, rendering without account history", "error", err)
+		} else {
+			dataStore = store
+			if closer, ok := store.(interface{ Close() error }); ok {
+				defer closer.Close()
+			}
+		}
+	}
+
+	dryRun := newDryRunner(cfg, file.NewFileProcessor(cfg.File.Reader, logger), services.NewSummaryCalculator(cfg.Summary), templates, dataStore, logger)
+
+	result, err := dryRun.Run(ctx, *path, *recipient)
+	if err != nil {
+		logger.Error("dry run failed", "error", err)
+		return 1
+	}
+
+	if *output == "" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(result)
+	} else {
+		err = writeDryRun(*output, result)
+	}
+	if err != nil {
+		logger.Error("failed to write dry run result", "error", err)
+		return 1
+	}
+
+	if !result.Valid {
+		return 1
+	}
+	return 0
+}
+
+// writeDryRun writes NAME.report.json, and NAME.summary.json and NAME.html when the unit
+// was summarized, for every unit of a dry run. NAME is the file name followed by the
+// archive member, if any
+func writeDryRun(dir string, result *services.DryRunResult) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	for _, unit := range result.Units {
+		name := filepath.Base(result.File) + strings.TrimPrefix(unit.Name, result.File)
+		name = strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(name)
+
+		report := services.DryRunUnit{Name: unit.Name, Report: unit.Report, Error: unit.Error}
+		if err := writeJSON(filepath.Join(dir, name+".report.json"), report); err != nil {
+			return err
+		}
+		if unit.Summary != nil {
+			if err := writeJSON(filepath.Join(dir, name+".summary.json"), unit.Summary); err != nil {
+				return err
+			}
+		}
+		if unit.HTML != "" {
+			if err := os.WriteFile(filepath.Join(dir, name+".html"), []byte(unit.HTML), 0o644); err != nil {
+				return fmt.Errorf("writing %s.html: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func writeJSON(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
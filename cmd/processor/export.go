@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/config"
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/file"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/iso20022"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/statement"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+// runExportCamt053 writes the persisted transactions of an account over a month as a
+// camt.053 statement
+func runExportCamt053(args []string) int {
+	flags := flag.NewFlagSet("export-camt053", flag.ContinueOnError)
+	account := flags.String("account", "", "email of the account to export")
+	period := flags.String("period", domain.NewYearMonth(time.Now()).AddMonths(-1).String(), "statement month as YYYY-MM")
+	currency := flags.String("currency", "", "currency of the statement and of transactions imported without one (default: the transactions' currency, else USD)")
+	output := flags.String("output", "", "output file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *account == "" || *output == "" {
+		fmt.Fprintln(os.Stderr, "--account and --output are required")
+		return 2
+	}
+	month, err := domain.ParseYearMonth(*period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --period: %v\n", err)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+	logger := setupLogger(cfg.Server.LogLevel)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	app, cleanup, err := initializeApplication(ctx, cfg, logger)
+	if err != nil {
+		logger.Error("failed to initialize application", "error", err)
+		return 1
+	}
+	defer cleanup()
+
+	if app.dataStore == nil {
+		fmt.Fprintln(os.Stderr, "exporting statements requires the database")
+		return 1
+	}
+
+	data, err := services.LoadAccountPeriod(ctx, app.dataStore, *account, domain.Period{From: month, To: month})
+	if err != nil {
+		logger.Error("failed to load account period", "account", *account, "period", month, "error", err)
+		return 1
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		logger.Error("failed to create output file", "file", *output, "error", err)
+		return 1
+	}
+	defer file.Close()
+
+	// Message IDs are limited to 35 characters, so only the start of the account ID is used
+	accountRef := data.Account.ID
+	if len(accountRef) > 8 {
+		accountRef = accountRef[:8]
+	}
+	statement := iso20022.StatementData{
+		ID:             fmt.Sprintf("STMT-%s-%s", month, accountRef),
+		AccountID:      data.Account.ID,
+		Currency:       *currency,
+		From:           month.Start(),
+		To:             month.End(),
+		Created:        time.Now().UTC(),
+		OpeningBalance: data.OpeningBalance,
+		Transactions:   data.Transactions,
+	}
+	if err := iso20022.WriteStatement(file, statement); err != nil {
+		logger.Error("failed to write statement", "error", err)
+		file.Close()
+		os.Remove(*output)
+		return 1
+	}
+
+	logger.Info("camt.053 statement exported", "file", *output, "account", *account, "period", month, "transactions", len(data.Transactions))
+	return 0
+}
+
+// runExport writes the persisted transactions of an account, optionally limited to a range
+// of months, as CSV, JSON or NDJSON
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	account := flags.String("account", "", "email of the account to export")
+	format := flags.String("format", file.FormatCSV, fmt.Sprintf("output format, one of %v", file.ExportFormats))
+	from := flags.String("from", "", "first month to export as YYYY-MM (default: all)")
+	to := flags.String("to", "", "last month to export as YYYY-MM (default: --from)")
+	output := flags.String("output", "", "output file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *account == "" || *output == "" {
+		fmt.Fprintln(os.Stderr, "--account and --output are required")
+		return 2
+	}
+	if !slices.Contains(file.ExportFormats, *format) {
+		fmt.Fprintf(os.Stderr, "--format must be one of %v\n", file.ExportFormats)
+		return 2
+	}
+	period, err := services.ParseExportPeriod(*from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid period: %v\n", err)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+	logger := setupLogger(cfg.Server.LogLevel)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	app, cleanup, err := initializeApplication(ctx, cfg, logger)
+	if err != nil {
+		logger.Error("failed to initialize application", "error", err)
+		return 1
+	}
+	defer cleanup()
+
+	if app.dataStore == nil {
+		fmt.Fprintln(os.Stderr, "exporting transactions requires the database")
+		return 1
+	}
+
+	found, err := services.FindAccount(ctx, app.dataStore, *account)
+	if err != nil {
+		logger.Error("failed to find account", "account", *account, "error", err)
+		return 1
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		logger.Error("failed to create output file", "file", *output, "error", err)
+		return 1
+	}
+
+	count := 0
+	buffered := bufio.NewWriter(out)
+	writer, err := file.NewTransactionWriter(*format, buffered)
+	if err == nil {
+		err = services.StreamAccountTransactions(ctx, app.dataStore, found.ID, period, func(transaction domain.Transaction) error {
+			count++
+			return writer.Write(transaction)
+		})
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// A truncated export could be imported again as if it were complete
+		logger.Error("transaction export failed", "file", *output, "error", err)
+		os.Remove(*output)
+		return 1
+	}
+
+	logger.Info("transactions exported", "file", *output, "account", *account, "format", *format, "transactions", count)
+	return 0
+}
+
+// runExportStatement writes the persisted transactions of an account over a range of months
+// as a paginated PDF statement
+func runExportStatement(args []string) int {
+	flags := flag.NewFlagSet("export-statement", flag.ContinueOnError)
+	account := flags.String("account", "", "email of the account to export")
+	from := flags.String("from", "", "first month of the statement as YYYY-MM (default: last month)")
+	to := flags.String("to", "", "last month of the statement as YYYY-MM (default: --from)")
+	locale := flags.String("locale", "", "statement language (default: the account's email locale)")
+	output := flags.String("output", "", "output file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *account == "" || *output == "" {
+		fmt.Fprintln(os.Stderr, "--account and --output are required")
+		return 2
+	}
+	period, err := services.ParseExportPeriod(*from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid period: %v\n", err)
+		return 2
+	}
+	if period == nil {
+		lastMonth := domain.NewYearMonth(time.Now()).AddMonths(-1)
+		period = &domain.Period{From: lastMonth, To: lastMonth}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+	language := cfg.Email.LocaleFor(*account)
+	if *locale != "" {
+		if language, err = i18n.Parse(*locale); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --locale: %v\n", err)
+			return 2
+		}
+	}
+	logger := setupLogger(cfg.Server.LogLevel)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	app, cleanup, err := initializeApplication(ctx, cfg, logger)
+	if err != nil {
+		logger.Error("failed to initialize application", "error", err)
+		return 1
+	}
+	defer cleanup()
+
+	if app.dataStore == nil {
+		fmt.Fprintln(os.Stderr, "exporting statements requires the database")
+		return 1
+	}
+
+	data, err := services.LoadAccountPeriod(ctx, app.dataStore, *account, *period)
+	if err != nil {
+		logger.Error("failed to load account period", "account", *account, "from", period.From, "to", period.To, "error", err)
+		return 1
+	}
+
+	summary := services.NewSummaryCalculator(cfg.Summary).Calculate(data.Transactions)
+	document, err := statement.RenderPDF(summary, data.Transactions, statement.Options{
+		Locale:         language,
+		Account:        data.Account.Email,
+		Period:         period,
+		OpeningBalance: &data.OpeningBalance,
+	})
+	if err != nil {
+		logger.Error("failed to render statement", "error", err)
+		return 1
+	}
+
+	if err := os.WriteFile(*output, document, 0o644); err != nil {
+		logger.Error("failed to write output file", "file", *output, "error", err)
+		return 1
+	}
+
+	logger.Info("PDF statement exported", "file", *output, "account", *account, "from", period.From, "to", period.To, "transactions", len(data.Transactions))
+	return 0
+}
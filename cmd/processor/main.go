@@ -2,24 +2,39 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/NahuelDT/stori-challenge/internal/api"
 	"github.com/NahuelDT/stori-challenge/internal/config"
+	"github.com/NahuelDT/stori-challenge/internal/cron"
 	"github.com/NahuelDT/stori-challenge/internal/infrastructure/database"
 	"github.com/NahuelDT/stori-challenge/internal/infrastructure/email"
 	"github.com/NahuelDT/stori-challenge/internal/infrastructure/file"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/notify"
 	"github.com/NahuelDT/stori-challenge/internal/services"
 )
 
 func main() {
+	command, args := "run", []string(nil)
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
+	}
+
+	os.Exit(runCommand(command, args))
+}
+
+// runProcessor watches the configured directory and processes incoming files until interrupted
+func runProcessor(args []string) int {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		slog.Error("failed to load configuration", "error", err)
-		os.Exit(1)
+		return 1
 	}
 
 	// Setup logger
@@ -33,30 +48,65 @@ func main() {
 	defer cancel()
 
 	// Initialize services
-	app, cleanup, err := initializeApplication(cfg, logger)
+	app, cleanup, err := initializeApplication(ctx, cfg, logger)
 	if err != nil {
 		logger.Error("failed to initialize application", "error", err)
-		os.Exit(1)
+		return 1
 	}
 	defer cleanup()
 
+	// Send scheduled monthly statements in the background
+	if cfg.Statements.Schedule != "" {
+		if app.statements == nil {
+			logger.Warn("monthly statements disabled: database unavailable")
+		} else {
+			go func() {
+				if err := app.statements.Run(ctx); err != nil && err != context.Canceled {
+					logger.Error("statement scheduler stopped", "error", err)
+				}
+			}()
+			logger.Info("monthly statements enabled", "schedule", cfg.Statements.Schedule)
+		}
+	}
+
+	// Serve the HTTP API in the background
+	if cfg.API.Enabled {
+		if app.dataStore == nil {
+			logger.Warn("API disabled: database unavailable")
+		} else {
+			server := api.NewServer(cfg.API, app.dataStore, app.dryRun, logger)
+			go func() {
+				if err := server.Run(ctx); err != nil && err != context.Canceled {
+					logger.Error("API server stopped", "error", err)
+				}
+			}()
+		}
+	}
+
 	// Start processing
 	recipientEmail := getRecipientEmail(cfg)
 	logger.Info("starting file processing",
 		"watch_directory", cfg.File.WatchDirectory,
 		"recipient", recipientEmail)
 
-	if err := app.WatchAndProcess(ctx, cfg.File.WatchDirectory, recipientEmail); err != nil {
+	if err := app.processor.WatchAndProcess(ctx, cfg.File.WatchDirectory, recipientEmail); err != nil {
 		if err == context.Canceled {
 			logger.Info("application stopped gracefully")
 		} else {
 			logger.Error("application error", "error", err)
-			os.Exit(1)
+			return 1
 		}
 	}
+
+	return 0
 }
 
 func setupLogger(level string) *slog.Logger {
+	return newLogger(os.Stdout, level)
+}
+
+// newLogger creates a text logger writing to w, for commands whose output goes to stdout
+func newLogger(w io.Writer, level string) *slog.Logger {
 	var logLevel slog.Level
 	switch level {
 	case "debug":
@@ -75,11 +125,20 @@ func setupLogger(level string) *slog.Logger {
 		Level: logLevel,
 	}
 
-	handler := slog.NewTextHandler(os.Stdout, opts)
+	handler := slog.NewTextHandler(w, opts)
 	return slog.New(handler)
 }
 
-func initializeApplication(cfg *config.Config, logger *slog.Logger) (*services.TransactionProcessor, func(), error) {
+// application holds the services wired from the configuration
+type application struct {
+	processor *services.TransactionProcessor
+	// dataStore and statements are nil when the database is unavailable
+	dataStore  services.DataStore
+	statements *services.StatementScheduler
+	dryRun     *services.DryRunner
+}
+
+func initializeApplication(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*application, func(), error) {
 	var cleanupFuncs []func()
 
 	cleanup := func() {
@@ -89,13 +148,34 @@ func initializeApplication(cfg *config.Config, logger *slog.Logger) (*services.T
 	}
 
 	// Initialize file processor
-	fileProcessor := file.NewCSVFileProcessor(logger)
+	fileProcessor := file.NewFileProcessor(cfg.File.Reader, logger)
+
+	// Initialize email templates
+	templates, err := email.NewTemplateStore(cfg.Email.TemplateDirectory, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading email templates: %w", err)
+	}
+	if cfg.Email.TemplateReload {
+		if err := templates.Watch(ctx); err != nil {
+			logger.Warn("email template hot reload disabled", "error", err)
+		}
+	}
 
-	// Initialize email service
-	emailService := email.NewSMTPEmailService(cfg.Email, logger)
+	// Initialize notification channels
+	notifier, err := buildNotifier(cfg, templates, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("initializing notification channels: %w", err)
+	}
+	if closer, ok := notifier.(interface{ Close() error }); ok {
+		cleanupFuncs = append(cleanupFuncs, func() {
+			if err := closer.Close(); err != nil {
+				logger.Error("failed to close notification channels", "error", err)
+			}
+		})
+	}
 
 	// Initialize summary calculator
-	calculator := services.NewSummaryCalculator()
+	calculator := services.NewSummaryCalculator(cfg.Summary)
 
 	// Initialize database (optional)
 	var dataStore services.DataStore
@@ -120,15 +200,69 @@ func initializeApplication(cfg *config.Config, logger *slog.Logger) (*services.T
 	}
 
 	// Create transaction processor
-	processor := services.NewTransactionProcessor(
-		fileProcessor,
-		emailService,
-		calculator,
-		dataStore,
-		logger,
-	)
-
-	return processor, cleanup, nil
+	app := &application{
+		processor: services.NewTransactionProcessor(
+			fileProcessor,
+			notifier,
+			calculator,
+			dataStore,
+			logger,
+		),
+		dataStore: dataStore,
+	}
+	app.processor.SetDirectories(services.FileDirectories{
+		Quarantine: cfg.File.QuarantineDir,
+		Processed:  cfg.File.ProcessedDir,
+	})
+
+	// Dry runs render emails without sending them
+	app.dryRun = newDryRunner(cfg, fileProcessor, calculator, templates, dataStore, logger)
+
+	// Monthly statements are built from persisted transactions
+	if dataStore != nil {
+		var schedule services.Schedule
+		if cfg.Statements.Schedule != "" {
+			parsed, err := cron.Parse(cfg.Statements.Schedule)
+			if err != nil {
+				return nil, nil, fmt.Errorf("parsing statement schedule: %w", err)
+			}
+			schedule = parsed
+		}
+		app.statements = services.NewStatementScheduler(schedule, dataStore, notifier, calculator, logger)
+		app.statements.SetClaimTimeout(cfg.Statements.ClaimTimeout)
+	}
+
+	return app, cleanup, nil
+}
+
+// newDryRunner creates a dry runner rendering emails with a mailbox email service, so
+// nothing can be sent. The data store, read for account history, may be nil
+func newDryRunner(cfg *config.Config, fileProcessor services.FileProcessor, calculator services.SummaryCalculator, templates *email.TemplateStore, dataStore services.DataStore, logger *slog.Logger) *services.DryRunner {
+	renderer := email.NewMailboxEmailService(cfg.Email, email.NewMailbox(), templates, logger)
+	return services.NewDryRunner(fileProcessor, calculator, renderer, dataStore, logger)
+}
+
+func buildNotifier(cfg *config.Config, templates *email.TemplateStore, logger *slog.Logger) (services.Notifier, error) {
+	var notifiers []services.Notifier
+
+	for _, channel := range cfg.Notify.Channels {
+		switch channel {
+		case notify.ChannelSMTP:
+			emailService, err := email.NewEmailService(cfg.Email, templates, logger)
+			if err != nil {
+				return nil, err
+			}
+			logger.Info("email driver selected", "driver", cfg.Email.Driver)
+			notifiers = append(notifiers, notify.NewEmailNotifier(emailService))
+		case notify.ChannelWebhook:
+			notifiers = append(notifiers, notify.NewWebhookNotifier(cfg.Notify.Webhook, logger))
+		case notify.ChannelFile:
+			notifiers = append(notifiers, notify.NewFileNotifier(cfg.Notify.FileSink, logger))
+		}
+		logger.Info("notification channel enabled", "channel", channel)
+	}
+
+	return services.NewFanOutNotifier(notifiers, logger), nil
 }
 
 func getRecipientEmail(cfg *config.Config) string {
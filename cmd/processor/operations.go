@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"os/user"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/config"
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+// defaultActor names the operator running an administrative command in the audit trail
+func defaultActor() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// runAdmin loads the configuration and wires the application for an administrative
+// command, which requires the database. Logs go to stderr so listings can be piped
+func runAdmin(fn func(ctx context.Context, cfg *config.Config, app *application, logger *slog.Logger) int) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+	logger := newLogger(os.Stderr, cfg.Server.LogLevel)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	app, cleanup, err := initializeApplication(ctx, cfg, logger)
+	if err != nil {
+		logger.Error("failed to initialize application", "error", err)
+		return 1
+	}
+	defer cleanup()
+
+	if app.dataStore == nil {
+		fmt.Fprintln(os.Stderr, "administrative commands require the database")
+		return 1
+	}
+	return fn(ctx, cfg, app, logger)
+}
+
+// runResend sends the summary of a past processing run again
+func runResend(args []string) int {
+	flags := flag.NewFlagSet("resend", flag.ContinueOnError)
+	runID := flags.String("run", "", "ID of the processing run to resend (see the runs command)")
+	actor := flags.String("actor", defaultActor(), "operator recorded in the audit trail")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *runID == "" {
+		fmt.Fprintln(os.Stderr, "--run is required")
+		return 2
+	}
+
+	return runAdmin(func(ctx context.Context, cfg *config.Config, app *application, logger *slog.Logger) int {
+		if err := app.processor.Resend(ctx, *runID, *actor); err != nil {
+			logger.Error("resend failed", "run_id", *runID, "error", err)
+			return 1
+		}
+		return 0
+	})
+}
+
+// runRecompute summarizes an account's stored transactions over a period and sends the summary
+func runRecompute(args []string) int {
+	flags := flag.NewFlagSet("recompute", flag.ContinueOnError)
+	account := flags.String("account", "", "email of the account")
+	from := flags.String("from", "", "first month as YYYY-MM (default: last month)")
+	to := flags.String("to", "", "last month as YYYY-MM (default: --from)")
+	actor := flags.String("actor", defaultActor(), "operator recorded in the audit trail")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *account == "" {
+		fmt.Fprintln(os.Stderr, "--account is required")
+		return 2
+	}
+	period, err := services.ParseExportPeriod(*from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid period: %v\n", err)
+		return 2
+	}
+	if period == nil {
+		lastMonth := domain.NewYearMonth(time.Now()).AddMonths(-1)
+		period = &domain.Period{From: lastMonth, To: lastMonth}
+	}
+
+	return runAdmin(func(ctx context.Context, cfg *config.Config, app *application, logger *slog.Logger) int {
+		if err := app.processor.Recompute(ctx, *account, *period, *actor); err != nil {
+			logger.Error("recompute failed", "account", *account, "error", err)
+			return 1
+		}
+		return 0
+	})
+}
+
+// runReprocess processes a quarantined file again, given by its quarantine run or its path
+func runReprocess(args []string) int {
+	flags := flag.NewFlagSet("reprocess", flag.ContinueOnError)
+	runID := flags.String("run", "", "ID of the quarantined run to reprocess (see the runs command)")
+	path := flags.String("file", "", "path of the file to reprocess, instead of --run")
+	recipient := flags.String("recipient", "", "recipient of the summary (default: the run's recipient, or RECIPIENT_EMAIL)")
+	actor := flags.String("actor", defaultActor(), "operator recorded in the audit trail")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if (*runID == "") == (*path == "") {
+		fmt.Fprintln(os.Stderr, "exactly one of --run and --file is required")
+		return 2
+	}
+
+	return runAdmin(func(ctx context.Context, cfg *config.Config, app *application, logger *slog.Logger) int {
+		filePath, to := *path, *recipient
+		if *runID != "" {
+			run, err := app.dataStore.GetProcessingRun(ctx, *runID)
+			if err != nil {
+				logger.Error("failed to load processing run", "run_id", *runID, "error", err)
+				return 1
+			}
+			if run.Status != domain.RunQuarantined {
+				fmt.Fprintf(os.Stderr, "run %s is %s, not quarantined; use resend instead\n", run.ID, run.Status)
+				return 1
+			}
+			filePath = run.QuarantinePath
+			if to == "" {
+				to = run.Recipient
+			}
+		}
+		if to == "" {
+			to = getRecipientEmail(cfg)
+		}
+
+		if err := app.processor.Reprocess(ctx, filePath, to, *actor); err != nil {
+			logger.Error("reprocess failed", "file", filePath, "error", err)
+			return 1
+		}
+		return 0
+	})
+}
+
+// runListRuns prints the latest processing runs
+func runListRuns(args []string) int {
+	flags := flag.NewFlagSet("runs", flag.ContinueOnError)
+	limit := flags.Int("limit", 20, "number of runs to list, newest first")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	return runAdmin(func(ctx context.Context, cfg *config.Config, app *application, logger *slog.Logger) int {
+		runs, err := app.dataStore.ListProcessingRuns(ctx, *limit)
+		if err != nil {
+			logger.Error("failed to list processing runs", "error", err)
+			return 1
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CREATED\tID\tSTATUS\tTRANSACTIONS\tRECIPIENT\tSOURCE\tDETAIL")
+		for _, run := range runs {
+			detail := run.Error
+			if run.QuarantinePath != "" {
+				detail = run.QuarantinePath + ": " + detail
+			}
+			if len(run.FailedMembers) > 0 {
+				detail = "members " + strings.Join(run.FailedMembers, ", ") + " of " + detail
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				run.CreatedAt.Format(time.DateTime), run.ID, run.Status, len(run.TransactionIDs), run.Recipient, run.Source, firstLine(detail))
+		}
+		w.Flush()
+		return 0
+	})
+}
+
+// runListAudit prints the latest entries of the audit trail
+func runListAudit(args []string) int {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	limit := flags.Int("limit", 20, "number of entries to list, newest first")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	return runAdmin(func(ctx context.Context, cfg *config.Config, app *application, logger *slog.Logger) int {
+		entries, err := app.dataStore.ListAuditEntries(ctx, *limit)
+		if err != nil {
+			logger.Error("failed to list audit entries", "error", err)
+			return 1
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tACTION\tACTOR\tTARGET\tOUTCOME")
+		for _, entry := range entries {
+			outcome := "ok"
+			if entry.Error != "" {
+				outcome = "failed: " + firstLine(entry.Error)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.CreatedAt.Format(time.DateTime), entry.Action, entry.Actor, entry.Target, outcome)
+		}
+		w.Flush()
+		return 0
+	})
+}
+
+// firstLine keeps listings on one line per row; joined errors span several
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
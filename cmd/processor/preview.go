@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/email"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/file"
+	"github.com/NahuelDT/stori-challenge/internal/preview"
+)
+
+// runPreview serves the summary email rendered against the built-in fixture summaries and
+// the given transaction files, reloading as the templates change, until interrupted
+func runPreview(args []string) int {
+	flags := flag.NewFlagSet("preview", flag.ContinueOnError)
+	addr := flags.String("addr", preview.DefaultAddr, "host or IP to listen on, 0.0.0.0 for every interface")
+	port := flags.String("port", "8081", "port to listen on")
+	dir := flags.String("dir", os.Getenv("EMAIL_TEMPLATE_DIRECTORY"), "template directory overriding the embedded templates, reloaded on change")
+	var files []string
+	flags.Func("file", "transaction file to preview, read again on every request (repeatable)", func(value string) error {
+		files = append(files, value)
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	logger := setupLogger(os.Getenv("LOG_LEVEL"))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	store, err := email.NewTemplateStore(*dir, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "templates failed to parse: %v\n", err)
+		return 1
+	}
+	if err := store.Watch(ctx); err != nil {
+		logger.Warn("template reload disabled", "error", err)
+	}
+
+	processor := file.NewFileProcessor(file.ReaderConfig{}, logger)
+	samples := preview.FixtureSamples()
+	names := make(map[string]bool, len(samples))
+	for _, sample := range samples {
+		names[sample.Name] = true
+	}
+	for _, path := range files {
+		// Samples are addressed by name, so two files may not share one
+		name := filepath.Base(path)
+		if names[name] {
+			fmt.Fprintf(os.Stderr, "duplicate sample name %q from %s: rename the file\n", name, path)
+			return 2
+		}
+		names[name] = true
+		samples = append(samples, preview.Sample{
+			Name: name,
+			Load: func(ctx context.Context) (*domain.Summary, error) {
+				transactions, err := processor.ProcessFile(ctx, path)
+				if err != nil {
+					return nil, err
+				}
+				return domain.NewSummary(transactions), nil
+			},
+		})
+	}
+
+	server := preview.NewServer(preview.Config{Addr: *addr, Port: *port}, store, samples, logger)
+	if err := server.Run(ctx); err != nil && err != context.Canceled {
+		logger.Error("preview server stopped", "error", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/config"
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+// runSendStatements sends the monthly statement of a period to every account now,
+// skipping accounts that already received it
+func runSendStatements(args []string) int {
+	flags := flag.NewFlagSet("send-statements", flag.ContinueOnError)
+	period := flags.String("period", domain.NewYearMonth(time.Now()).AddMonths(-1).String(), "statement month as YYYY-MM")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	month, err := domain.ParseYearMonth(*period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --period: %v\n", err)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+	logger := setupLogger(cfg.Server.LogLevel)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	app, cleanup, err := initializeApplication(ctx, cfg, logger)
+	if err != nil {
+		logger.Error("failed to initialize application", "error", err)
+		return 1
+	}
+	defer cleanup()
+
+	if app.statements == nil {
+		fmt.Fprintln(os.Stderr, "monthly statements require the database")
+		return 1
+	}
+
+	if err := app.statements.SendStatements(ctx, month); err != nil {
+		logger.Error("some statements failed", "period", month, "error", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/email"
+)
+
+// runValidateTemplates parses the email templates and renders them against the
+// built-in fixture summaries, so broken templates are caught before deploying
+func runValidateTemplates(args []string) int {
+	flags := flag.NewFlagSet("validate-templates", flag.ContinueOnError)
+	dir := flags.String("dir", os.Getenv("EMAIL_TEMPLATE_DIRECTORY"), "template directory overriding the embedded templates")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	logger := setupLogger(os.Getenv("LOG_LEVEL"))
+
+	store, err := email.NewTemplateStore(*dir, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "templates failed to parse: %v\n", err)
+		return 1
+	}
+
+	if err := store.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "templates failed to render:\n%v\n", err)
+		return 1
+	}
+
+	for _, fixture := range email.Fixtures() {
+		fmt.Printf("ok  %s\n", fixture.Name)
+	}
+	return 0
+}
@@ -3,9 +3,11 @@ module github.com/NahuelDT/stori-challenge
 go 1.24.1
 
 require (
-	github.com/fsnotify/fsnotify v1.9.0 // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
-	github.com/shopspring/decimal v1.4.0 // indirect
-	golang.org/x/sys v0.13.0 // indirect
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/google/uuid v1.6.0
+	github.com/klauspost/compress v1.18.0
+	github.com/lib/pq v1.10.9
+	github.com/shopspring/decimal v1.4.0
 )
+
+require golang.org/x/sys v0.13.0 // indirect
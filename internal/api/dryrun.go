@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxUploadSize bounds the size of a file posted for a dry run
+const maxUploadSize = 32 << 20
+
+// handleDryRun reads, validates and summarizes the posted file and renders its email,
+// without saving or sending anything:
+// POST /dry-run?filename=NAME[&recipient=EMAIL] with the file as the request body
+func (s *Server) handleDryRun(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// The name selects the format, as the extension of a watched file does
+	name := filepath.Base(query.Get("filename"))
+	if name == "." || name == string(filepath.Separator) {
+		writeError(w, http.StatusBadRequest, errors.New("filename is required"))
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "stori-dry-run-")
+	if err != nil {
+		s.logger.Error("failed to create dry run directory", "error", err)
+		writeError(w, http.StatusInternalServerError, errors.New("failed to store file"))
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, name)
+	if err := saveUpload(path, http.MaxBytesReader(w, r.Body, maxUploadSize)); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("file exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		s.logger.Error("failed to store dry run file", "error", err)
+		writeError(w, http.StatusInternalServerError, errors.New("failed to store file"))
+		return
+	}
+
+	result, err := s.dryRun.Run(r.Context(), path, query.Get("recipient"))
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, errors.New(strings.ReplaceAll(err.Error(), path, name)))
+		return
+	}
+
+	// Report the file under its uploaded name rather than the temporary path
+	result.File = name
+	for i := range result.Units {
+		result.Units[i].Name = name + strings.TrimPrefix(result.Units[i].Name, path)
+		result.Units[i].Error = strings.ReplaceAll(result.Units[i].Error, path, name)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		s.logger.Error("failed to write dry run result", "error", err)
+	}
+}
+
+func saveUpload(path string, body io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
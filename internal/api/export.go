@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/file"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+// handleExport streams the transactions of an account:
+// GET /transactions?account=EMAIL[&format=csv|json|ndjson][&from=YYYY-MM][&to=YYYY-MM]
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	email := query.Get("account")
+	if email == "" {
+		writeError(w, http.StatusBadRequest, errors.New("account is required"))
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = file.FormatJSON
+	}
+	if !slices.Contains(file.ExportFormats, format) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("format must be one of %v", file.ExportFormats))
+		return
+	}
+	period, err := services.ParseExportPeriod(query.Get("from"), query.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	account, err := services.FindAccount(r.Context(), s.dataStore, email)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		s.logger.Error("failed to find account", "account", email, "error", err)
+		writeError(w, http.StatusInternalServerError, errors.New("failed to find account"))
+		return
+	}
+
+	w.Header().Set("Content-Type", file.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="transactions.%s"`, format))
+	writer, err := file.NewTransactionWriter(format, w)
+	if err != nil {
+		s.logger.Error("failed to start export", "error", err)
+		return
+	}
+
+	count := 0
+	err = services.StreamAccountTransactions(r.Context(), s.dataStore, account.ID, period, func(transaction domain.Transaction) error {
+		count++
+		return writer.Write(transaction)
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// The status is already sent, so the truncated body is the only signal to the client
+		s.logger.Error("transaction export failed", "account", email, "format", format, "exported", count, "error", err)
+		return
+	}
+
+	s.logger.Info("transactions exported", "account", email, "format", format, "transactions", count)
+}
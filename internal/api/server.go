@@ -0,0 +1,102 @@
+// Package api serves the HTTP API over persisted accounts and transactions, and dry runs
+// of transaction files
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+// shutdownTimeout bounds the wait for in-flight requests when the server stops
+const shutdownTimeout = 10 * time.Second
+
+// Config configures the HTTP API
+type Config struct {
+	Enabled bool
+	Port    string
+	// Token is the bearer token every request must present
+	Token string
+}
+
+// Server is the HTTP API
+type Server struct {
+	config    Config
+	dataStore services.DataStore
+	dryRun    *services.DryRunner
+	logger    *slog.Logger
+	mux       *http.ServeMux
+}
+
+// NewServer creates the HTTP API over a data store. Dry runs are served when dryRun is not nil
+func NewServer(config Config, dataStore services.DataStore, dryRun *services.DryRunner, logger *slog.Logger) *Server {
+	s := &Server{
+		config:    config,
+		dataStore: dataStore,
+		dryRun:    dryRun,
+		logger:    logger,
+		mux:       http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /transactions", s.handleExport)
+	if dryRun != nil {
+		s.mux.HandleFunc("POST /dry-run", s.handleDryRun)
+	}
+	return s
+}
+
+// Handler returns the API handler, which requires the bearer token on every request
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="stori"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		s.mux.ServeHTTP(w, r)
+	})
+}
+
+// Run serves the API on the configured port until ctx is canceled
+func (s *Server) Run(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              net.JoinHostPort("", s.config.Port),
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("API server listening", "address", server.Addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("serving API: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("shutting down API: %w", err)
+		}
+		return ctx.Err()
+	}
+}
+
+// writeError writes an error as a JSON body
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
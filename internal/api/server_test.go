@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/file"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+	"github.com/shopspring/decimal"
+)
+
+// memoryStore is a data store holding the transactions of a single account
+type memoryStore struct {
+	services.DataStore
+	account      domain.Account
+	transactions []domain.Transaction
+}
+
+func (m *memoryStore) GetAccountByEmail(ctx context.Context, email string) (domain.Account, error) {
+	if !strings.EqualFold(email, m.account.Email) {
+		return domain.Account{}, domain.ErrAccountNotFound
+	}
+	return m.account, nil
+}
+
+func (m *memoryStore) StreamTransactions(ctx context.Context, accountID string, startDate, endDate time.Time, fn func(domain.Transaction) error) error {
+	for _, transaction := range m.transactions {
+		if !endDate.IsZero() && (transaction.Date.Before(startDate) || transaction.Date.After(endDate)) {
+			continue
+		}
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportTransactions(t *testing.T) {
+	store := &memoryStore{
+		account: domain.Account{ID: "account-1", Email: "user@example.com"},
+		transactions: []domain.Transaction{
+			{ID: 1, Date: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("5"), Type: domain.Credit},
+			{ID: 2, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("60.5"), Type: domain.Credit},
+			{ID: 3, Date: time.Date(2024, 7, 28, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("10.3"), Type: domain.Debit},
+		},
+	}
+	server := NewServer(Config{Token: "secret"}, store, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := server.Handler()
+
+	get := func(target, token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if got := get("/transactions?account=user@example.com", "wrong").Code; got != http.StatusUnauthorized {
+		t.Errorf("wrong token: status %d, want 401", got)
+	}
+	if got := get("/transactions?account=other@example.com", "secret").Code; got != http.StatusNotFound {
+		t.Errorf("unknown account: status %d, want 404", got)
+	}
+	if got := get("/transactions?account=user@example.com&format=xml", "secret").Code; got != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d, want 400", got)
+	}
+
+	response := get("/transactions?account=user@example.com&format=csv&from=2024-07", "secret")
+	if response.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", response.Code, response.Body)
+	}
+	if got := response.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+		t.Errorf("Content-Type = %q", got)
+	}
+	want := "Id,Date,Transaction\n2,2024-07-15,+60.50\n3,2024-07-28,-10.30\n"
+	if response.Body.String() != want {
+		t.Errorf("body = %q, want %q", response.Body.String(), want)
+	}
+
+	response = get("/transactions?account=user@example.com&format=ndjson", "secret")
+	if lines := strings.Count(response.Body.String(), "\n"); lines != 3 {
+		t.Errorf("ndjson export has %d lines, want 3:\n%s", lines, response.Body)
+	}
+}
+
+// stubRenderer renders a summary as its total balance
+type stubRenderer struct{}
+
+func (stubRenderer) RenderTemplate(recipient string, summary *domain.Summary) (string, error) {
+	return "<p>" + recipient + " " + summary.TotalBalance.String() + "</p>", nil
+}
+
+func TestDryRun(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dryRun := services.NewDryRunner(file.NewFileProcessor(file.ReaderConfig{}, logger), services.NewSummaryCalculator(domain.SummaryOptions{}), stubRenderer{}, nil, logger)
+	handler := NewServer(Config{Token: "secret"}, &memoryStore{}, dryRun, logger).Handler()
+
+	post := func(target, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer secret")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if got := post("/dry-run", "").Code; got != http.StatusBadRequest {
+		t.Errorf("missing filename: status %d, want 400", got)
+	}
+
+	response := post("/dry-run?filename=partner.csv&recipient=user@example.com", "Id,Date,Transaction\n1,7/15,+60.5\n1,7/28,-10.3\n")
+	if response.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", response.Code, response.Body)
+	}
+	var result services.DryRunResult
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decoding result: %v", err)
+	}
+
+	if result.File != "partner.csv" || len(result.Units) != 1 || result.Units[0].Name != "partner.csv" {
+		t.Fatalf("result = %+v, want one unit named partner.csv", result)
+	}
+	unit := result.Units[0]
+	if result.Valid || len(unit.Report.Issues) != 1 || unit.Report.Issues[0].TransactionID != 1 {
+		t.Errorf("valid = %v, issues = %+v, want the duplicate ID reported", result.Valid, unit.Report.Issues)
+	}
+	if unit.Summary == nil || unit.HTML != "<p>user@example.com 50.2</p>" {
+		t.Errorf("summary = %+v, html = %q", unit.Summary, unit.HTML)
+	}
+}
@@ -5,16 +5,27 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/NahuelDT/stori-challenge/internal/api"
+	"github.com/NahuelDT/stori-challenge/internal/cron"
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
 	"github.com/NahuelDT/stori-challenge/internal/infrastructure/database"
 	"github.com/NahuelDT/stori-challenge/internal/infrastructure/email"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/file"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/notify"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Email    email.SMTPConfig
-	Database database.PostgresConfig
-	File     FileConfig
+	Server     ServerConfig
+	Email      email.SMTPConfig
+	Database   database.PostgresConfig
+	File       FileConfig
+	Notify     NotifyConfig
+	Summary    domain.SummaryOptions
+	Statements StatementsConfig
+	API        api.Config
 }
 
 type ServerConfig struct {
@@ -26,6 +37,22 @@ type ServerConfig struct {
 type FileConfig struct {
 	WatchDirectory string
 	ProcessedDir   string
+	// QuarantineDir receives files that cannot be read; empty leaves them in place
+	QuarantineDir string
+	Reader        file.ReaderConfig
+}
+
+type StatementsConfig struct {
+	// Schedule is the cron expression for sending monthly statements; empty disables them
+	Schedule string
+	// ClaimTimeout is after how long a statement left unsent by a stopped sender is retried
+	ClaimTimeout time.Duration
+}
+
+type NotifyConfig struct {
+	Channels []string
+	Webhook  notify.WebhookConfig
+	FileSink notify.FileSinkConfig
 }
 
 // Load loads configuration from environment variables
@@ -42,6 +69,23 @@ func Load() (*Config, error) {
 			Username: os.Getenv("SMTP_USERNAME"),
 			Password: os.Getenv("SMTP_PASSWORD"),
 			From:     getEnvOrDefault("SMTP_FROM", "noreply@stori.com"),
+
+			TLSMode:       strings.ToLower(getEnvOrDefault("SMTP_TLS_MODE", email.TLSStartTLSOptional)),
+			AuthMechanism: strings.ToLower(getEnvOrDefault("SMTP_AUTH", email.DefaultAuthMechanism(os.Getenv("SMTP_USERNAME")))),
+			CAFile:        os.Getenv("SMTP_CA_FILE"),
+
+			DKIM: email.DKIMConfig{
+				Domain:         os.Getenv("DKIM_DOMAIN"),
+				Selector:       os.Getenv("DKIM_SELECTOR"),
+				PrivateKeyFile: os.Getenv("DKIM_PRIVATE_KEY_FILE"),
+			},
+
+			TemplateDirectory: os.Getenv("EMAIL_TEMPLATE_DIRECTORY"),
+			TemplateReload:    getEnvOrDefault("EMAIL_TEMPLATE_RELOAD", "false") == "true",
+			Statistics:        getEnvOrDefault("EMAIL_STATISTICS", "false") == "true",
+
+			Driver:        strings.ToLower(getEnvOrDefault("EMAIL_DRIVER", email.DriverSMTP)),
+			FileDirectory: getEnvOrDefault("EMAIL_FILE_DIRECTORY", "/data/outbox"),
 		},
 		Database: database.PostgresConfig{
 			Host:     getEnvOrDefault("DB_HOST", "localhost"),
@@ -54,9 +98,118 @@ func Load() (*Config, error) {
 		File: FileConfig{
 			WatchDirectory: getEnvOrDefault("WATCH_DIRECTORY", "/data"),
 			ProcessedDir:   getEnvOrDefault("PROCESSED_DIRECTORY", "/data/processed"),
+			QuarantineDir:  getEnvOrDefault("QUARANTINE_DIRECTORY", "/data/quarantine"),
+			Reader: file.ReaderConfig{
+				XLSXSheet: os.Getenv("XLSX_SHEET"),
+			},
+		},
+		Statements: StatementsConfig{
+			Schedule: os.Getenv("STATEMENT_SCHEDULE"),
+		},
+		API: api.Config{
+			Enabled: getEnvOrDefault("API_ENABLED", "false") == "true",
+			Port:    getEnvOrDefault("PORT", "8080"),
+			Token:   os.Getenv("API_TOKEN"),
+		},
+		Notify: NotifyConfig{
+			Channels: splitList(getEnvOrDefault("NOTIFY_CHANNELS", notify.ChannelSMTP)),
+			Webhook: notify.WebhookConfig{
+				URL:    os.Getenv("WEBHOOK_URL"),
+				Secret: os.Getenv("WEBHOOK_SECRET"),
+			},
+			FileSink: notify.FileSinkConfig{
+				Directory: getEnvOrDefault("NOTIFY_FILE_DIRECTORY", "/data/notifications"),
+				Layout:    getEnvOrDefault("NOTIFY_FILE_LAYOUT", notify.LayoutFlat),
+			},
 		},
 	}
 
+	attachments, err := email.ParseAttachmentKinds(os.Getenv("EMAIL_ATTACHMENTS"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing EMAIL_ATTACHMENTS: %w", err)
+	}
+	config.Email.Attachments = attachments
+
+	accountAttachments, err := email.ParseAccountAttachments(os.Getenv("EMAIL_ACCOUNT_ATTACHMENTS"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing EMAIL_ACCOUNT_ATTACHMENTS: %w", err)
+	}
+	config.Email.AccountAttachments = accountAttachments
+
+	locale, err := i18n.Parse(getEnvOrDefault("EMAIL_LOCALE", string(i18n.DefaultLocale)))
+	if err != nil {
+		return nil, fmt.Errorf("parsing EMAIL_LOCALE: %w", err)
+	}
+	config.Email.Locale = locale
+
+	accountLocales, err := i18n.ParseAccountLocales(os.Getenv("EMAIL_ACCOUNT_LOCALES"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing EMAIL_ACCOUNT_LOCALES: %w", err)
+	}
+	config.Email.AccountLocales = accountLocales
+
+	if config.Email.DKIM.PrivateKeyFile != "" {
+		key, err := email.LoadDKIMKey(config.Email.DKIM.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading DKIM_PRIVATE_KEY_FILE: %w", err)
+		}
+		config.Email.DKIM.Key = key
+	}
+
+	smtpTimeout, err := getEnvDurationOrDefault("SMTP_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.Email.Timeout = smtpTimeout
+
+	claimTimeout, err := getEnvDurationOrDefault("STATEMENT_CLAIM_TIMEOUT", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	config.Statements.ClaimTimeout = claimTimeout
+
+	poolIdleTimeout, err := getEnvDurationOrDefault("SMTP_POOL_IDLE_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.Email.PoolIdleTimeout = poolIdleTimeout
+
+	poolSize, err := strconv.Atoi(getEnvOrDefault("SMTP_POOL_SIZE", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing SMTP_POOL_SIZE: %w", err)
+	}
+	config.Email.PoolSize = poolSize
+
+	rateLimit, err := strconv.ParseFloat(getEnvOrDefault("SMTP_RATE_LIMIT", "0"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing SMTP_RATE_LIMIT: %w", err)
+	}
+	config.Email.RateLimit = rateLimit
+
+	maxDecompressedSize, err := strconv.ParseInt(getEnvOrDefault("MAX_DECOMPRESSED_SIZE", strconv.Itoa(file.DefaultMaxDecompressedSize)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing MAX_DECOMPRESSED_SIZE: %w", err)
+	}
+	config.File.Reader.MaxDecompressedSize = maxDecompressedSize
+
+	maxArchiveMembers, err := strconv.Atoi(getEnvOrDefault("MAX_ARCHIVE_MEMBERS", strconv.Itoa(file.DefaultMaxArchiveMembers)))
+	if err != nil {
+		return nil, fmt.Errorf("parsing MAX_ARCHIVE_MEMBERS: %w", err)
+	}
+	config.File.Reader.MaxArchiveMembers = maxArchiveMembers
+
+	topTransactions, err := strconv.Atoi(getEnvOrDefault("SUMMARY_TOP_TRANSACTIONS", strconv.Itoa(domain.DefaultTopTransactions)))
+	if err != nil {
+		return nil, fmt.Errorf("parsing SUMMARY_TOP_TRANSACTIONS: %w", err)
+	}
+	config.Summary.TopTransactions = topTransactions
+
+	webhookTimeout, err := getEnvDurationOrDefault("WEBHOOK_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.Notify.Webhook.Timeout = webhookTimeout
+
 	if err := config.validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
@@ -82,20 +235,120 @@ func (c *Config) DatabaseEnabled() bool {
 		c.Database.DBName != ""
 }
 
+// NotifyChannelEnabled returns true if the given notification channel is configured
+func (c *Config) NotifyChannelEnabled(channel string) bool {
+	return contains(c.Notify.Channels, channel)
+}
+
 func (c *Config) validate() error {
 	var errors []string
 
-	if c.Email.Username == "" {
-		errors = append(errors, "SMTP_USERNAME is required")
+	validDrivers := []string{email.DriverSMTP, email.DriverFile}
+	if !contains(validDrivers, c.Email.Driver) {
+		errors = append(errors, fmt.Sprintf("EMAIL_DRIVER must be one of: %s", strings.Join(validDrivers, ", ")))
+	}
+
+	if c.Email.DKIM.Enabled() {
+		if c.Email.DKIM.Domain == "" {
+			errors = append(errors, "DKIM_DOMAIN is required when DKIM_PRIVATE_KEY_FILE is set")
+		}
+		if c.Email.DKIM.Selector == "" {
+			errors = append(errors, "DKIM_SELECTOR is required when DKIM_PRIVATE_KEY_FILE is set")
+		}
+	}
+
+	if c.NotifyChannelEnabled(notify.ChannelSMTP) {
+		switch c.Email.Driver {
+		case email.DriverSMTP:
+			validTLSModes := []string{email.TLSStartTLS, email.TLSStartTLSOptional, email.TLSImplicit, email.TLSNone}
+			if !contains(validTLSModes, c.Email.TLSMode) {
+				errors = append(errors, fmt.Sprintf("SMTP_TLS_MODE must be one of: %s", strings.Join(validTLSModes, ", ")))
+			}
+
+			validAuth := []string{email.AuthPlain, email.AuthLogin, email.AuthCRAMMD5, email.AuthNone}
+			if !contains(validAuth, c.Email.AuthMechanism) {
+				errors = append(errors, fmt.Sprintf("SMTP_AUTH must be one of: %s", strings.Join(validAuth, ", ")))
+			}
+
+			if c.Email.PoolSize < 0 {
+				errors = append(errors, "SMTP_POOL_SIZE must not be negative")
+			}
+			if c.Email.RateLimit < 0 {
+				errors = append(errors, "SMTP_RATE_LIMIT must not be negative")
+			}
+
+			if c.Email.AuthMechanism != email.AuthNone {
+				if c.Email.Username == "" {
+					errors = append(errors, "SMTP_USERNAME is required")
+				}
+				if c.Email.Password == "" {
+					errors = append(errors, "SMTP_PASSWORD is required")
+				}
+			}
+		case email.DriverFile:
+			if c.Email.FileDirectory == "" {
+				errors = append(errors, "EMAIL_FILE_DIRECTORY is required when EMAIL_DRIVER is file")
+			}
+		}
+	}
+
+	if c.Statements.Schedule != "" {
+		if _, err := cron.Parse(c.Statements.Schedule); err != nil {
+			errors = append(errors, fmt.Sprintf("STATEMENT_SCHEDULE is invalid: %v", err))
+		}
+		if !c.DatabaseEnabled() {
+			errors = append(errors, "STATEMENT_SCHEDULE requires the database to be configured")
+		}
+	}
+	if c.Statements.ClaimTimeout <= 0 {
+		errors = append(errors, "STATEMENT_CLAIM_TIMEOUT must be positive")
+	}
+
+	if c.Summary.TopTransactions < 0 {
+		errors = append(errors, "SUMMARY_TOP_TRANSACTIONS must not be negative")
+	}
+
+	if c.API.Enabled {
+		if c.API.Token == "" {
+			errors = append(errors, "API_TOKEN is required when API_ENABLED is true")
+		}
+		if !c.DatabaseEnabled() {
+			errors = append(errors, "API_ENABLED requires the database to be configured")
+		}
 	}
-	if c.Email.Password == "" {
-		errors = append(errors, "SMTP_PASSWORD is required")
+
+	if c.File.Reader.MaxDecompressedSize <= 0 {
+		errors = append(errors, "MAX_DECOMPRESSED_SIZE must be positive")
+	}
+	if c.File.Reader.MaxArchiveMembers <= 0 {
+		errors = append(errors, "MAX_ARCHIVE_MEMBERS must be positive")
 	}
 
 	if c.File.WatchDirectory == "" {
 		errors = append(errors, "WATCH_DIRECTORY is required")
 	}
 
+	validChannels := []string{notify.ChannelSMTP, notify.ChannelWebhook, notify.ChannelFile}
+	if len(c.Notify.Channels) == 0 {
+		errors = append(errors, "NOTIFY_CHANNELS must list at least one channel")
+	}
+	for _, channel := range c.Notify.Channels {
+		if !contains(validChannels, channel) {
+			errors = append(errors, fmt.Sprintf("NOTIFY_CHANNELS entries must be one of: %s", strings.Join(validChannels, ", ")))
+		}
+	}
+	if c.NotifyChannelEnabled(notify.ChannelWebhook) && c.Notify.Webhook.URL == "" {
+		errors = append(errors, "WEBHOOK_URL is required when the webhook channel is enabled")
+	}
+	if c.NotifyChannelEnabled(notify.ChannelFile) {
+		if c.Notify.FileSink.Directory == "" {
+			errors = append(errors, "NOTIFY_FILE_DIRECTORY is required when the file channel is enabled")
+		}
+		if !contains([]string{notify.LayoutFlat, notify.LayoutSpool}, c.Notify.FileSink.Layout) {
+			errors = append(errors, "NOTIFY_FILE_LAYOUT must be one of: flat, spool")
+		}
+	}
+
 	if port, err := strconv.Atoi(c.Server.Port); err != nil || port <= 0 || port > 65535 {
 		errors = append(errors, "PORT must be a valid port number (1-65535)")
 	}
@@ -119,6 +372,29 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", key, err)
+	}
+	return duration, nil
+}
+
+// splitList splits a comma separated list, trimming and lower-casing entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -0,0 +1,64 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2024, time.July, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 8 1 * *", time.Date(2024, time.August, 1, 8, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.July, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, time.July, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, time.July, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.July, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 20th is a Saturday, the 17th a Wednesday)
+		{"0 0 20 * 3", time.Date(2024, time.July, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		schedule, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.expr, err)
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNextFractionalOffset(t *testing.T) {
+	schedule, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, zone := range []*time.Location{
+		time.FixedZone("IST", 5*3600+30*60),
+		time.FixedZone("ACWST", 8*3600+45*60),
+		time.FixedZone("NST", -(3*3600 + 30*60)),
+	} {
+		from := time.Date(2024, time.July, 15, 7, 10, 0, 0, zone)
+		want := time.Date(2024, time.July, 15, 9, 0, 0, 0, zone)
+		if got := schedule.Next(from); !got.Equal(want) {
+			t.Errorf("Next(%s) = %s, want %s", from, got, want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", expr)
+		}
+	}
+}
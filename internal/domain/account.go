@@ -0,0 +1,7 @@
+package domain
+
+// Account is a customer account that receives statements
+type Account struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
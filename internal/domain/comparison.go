@@ -0,0 +1,85 @@
+package domain
+
+import "github.com/shopspring/decimal"
+
+// Period is an inclusive range of calendar months
+type Period struct {
+	From YearMonth `json:"from"`
+	To   YearMonth `json:"to"`
+}
+
+// Months returns the number of months in the period
+func (p Period) Months() int {
+	return (p.To.Year-p.From.Year)*12 + int(p.To.Month-p.From.Month) + 1
+}
+
+// Previous returns the period of the same length that ends right before p
+func (p Period) Previous() Period {
+	return Period{
+		From: p.From.AddMonths(-p.Months()),
+		To:   p.From.AddMonths(-1),
+	}
+}
+
+// Change compares a figure between two periods
+type Change struct {
+	Current  decimal.Decimal `json:"current"`
+	Previous decimal.Decimal `json:"previous"`
+	Delta    decimal.Decimal `json:"delta"`
+	// Percent is the delta relative to the previous value, nil when the previous value is zero
+	Percent *decimal.Decimal `json:"percent"`
+}
+
+func newChange(current, previous decimal.Decimal) Change {
+	change := Change{
+		Current:  current,
+		Previous: previous,
+		Delta:    current.Sub(previous),
+	}
+	if !previous.IsZero() {
+		percent := change.Delta.Div(previous.Abs()).Mul(decimal.NewFromInt(100)).Round(2)
+		change.Percent = &percent
+	}
+	return change
+}
+
+// PeriodComparison compares the activity of a summary with the previous period of the same length
+type PeriodComparison struct {
+	Period         Period `json:"period"`
+	PreviousPeriod Period `json:"previous_period"`
+	Income         Change `json:"income"`
+	Spend          Change `json:"spend"`
+	// Net is the balance change over each period
+	Net Change `json:"net"`
+}
+
+// NewPeriodComparison compares the current summary with the transactions of the previous period
+func NewPeriodComparison(current *Summary, previous []Transaction) *PeriodComparison {
+	period, ok := current.Period()
+	if !ok {
+		return nil
+	}
+
+	var income, spend decimal.Decimal
+	for _, month := range current.Months() {
+		income = income.Add(current.MonthlyCredits[month])
+		spend = spend.Add(current.MonthlyDebits[month])
+	}
+
+	var previousIncome, previousSpend decimal.Decimal
+	for _, transaction := range previous {
+		if transaction.IsCredit() {
+			previousIncome = previousIncome.Add(transaction.Amount)
+		} else {
+			previousSpend = previousSpend.Add(transaction.Amount)
+		}
+	}
+
+	return &PeriodComparison{
+		Period:         period,
+		PreviousPeriod: period.Previous(),
+		Income:         newChange(income, previousIncome),
+		Spend:          newChange(spend, previousSpend),
+		Net:            newChange(income.Sub(spend), previousIncome.Sub(previousSpend)),
+	}
+}
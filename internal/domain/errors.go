@@ -11,4 +11,6 @@ var (
 	ErrInvalidFileFormat    = errors.New("invalid file format")
 	ErrEmailDeliveryFailed  = errors.New("email delivery failed")
 	ErrDatabaseConnection   = errors.New("database connection failed")
+	ErrAccountNotFound      = errors.New("account not found")
+	ErrRunNotFound          = errors.New("processing run not found")
 )
@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// Processing run statuses
+const (
+	// RunSent is a file unit whose summary was delivered
+	RunSent = "sent"
+	// RunFailed is a file unit that was read but whose summary could not be delivered
+	RunFailed = "failed"
+	// RunQuarantined is a file that could not be read and was moved aside for inspection
+	RunQuarantined = "quarantined"
+)
+
+// ProcessingRun records the processing of one unit of an input file
+type ProcessingRun struct {
+	ID        string `json:"id"`
+	Source    string `json:"source"`
+	Recipient string `json:"recipient"`
+	// AccountID is empty when the transactions were not persisted
+	AccountID string `json:"account_id,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	// TransactionIDs are the transactions read from the unit, which a resend summarizes again
+	TransactionIDs []int `json:"transaction_ids"`
+	// TransactionReferences holds the reference of each of TransactionIDs, empty for
+	// transactions without one. IDs derived from references are not unique, so a
+	// transaction is identified by both
+	TransactionReferences []string `json:"transaction_references,omitempty"`
+	// QuarantinePath is where a quarantined file was moved
+	QuarantinePath string `json:"quarantine_path,omitempty"`
+	// FailedMembers are the archive members of a quarantined file that could not be read,
+	// the only ones reprocessing reads again; empty when the whole file failed
+	FailedMembers []string  `json:"failed_members,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Audited administrative actions
+const (
+	AuditResend    = "resend"
+	AuditRecompute = "recompute"
+	AuditReprocess = "reprocess"
+)
+
+// AuditEntry records an administrative action and its outcome
+type AuditEntry struct {
+	Action string `json:"action"`
+	// Actor is who requested the action, e.g. the operator's user name
+	Actor string `json:"actor"`
+	// Target identifies what the action applied to: a run ID, an account and period, or a file
+	Target string `json:"target"`
+	// Error is empty when the action succeeded
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultTopTransactions is the number of largest transactions kept in a summary
+const DefaultTopTransactions = 5
+
+// SummaryOptions tunes the statistics computed by NewSummaryWithOptions
+type SummaryOptions struct {
+	// TopTransactions is the number of largest transactions to keep; zero keeps none
+	TopTransactions int
+}
+
+// AmountStats describes the distribution of the amounts of one transaction type
+type AmountStats struct {
+	Count  int             `json:"count"`
+	Min    decimal.Decimal `json:"min"`
+	Max    decimal.Decimal `json:"max"`
+	Median decimal.Decimal `json:"median"`
+	P25    decimal.Decimal `json:"p25"`
+	P75    decimal.Decimal `json:"p75"`
+	P90    decimal.Decimal `json:"p90"`
+}
+
+func newAmountStats(amounts []decimal.Decimal) AmountStats {
+	if len(amounts) == 0 {
+		return AmountStats{}
+	}
+
+	sorted := make([]decimal.Decimal, len(amounts))
+	copy(sorted, amounts)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].LessThan(sorted[j])
+	})
+
+	return AmountStats{
+		Count:  len(sorted),
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Median: percentile(sorted, 50),
+		P25:    percentile(sorted, 25),
+		P75:    percentile(sorted, 75),
+		P90:    percentile(sorted, 90),
+	}
+}
+
+// percentile returns the p-th percentile (0-100) of sorted amounts, interpolating
+// linearly between the closest ranks
+func percentile(sorted []decimal.Decimal, p float64) decimal.Decimal {
+	if len(sorted) == 0 {
+		return decimal.Zero
+	}
+
+	rank := decimal.NewFromFloat(p).Div(decimal.NewFromInt(100)).Mul(decimal.NewFromInt(int64(len(sorted) - 1)))
+	lower := int(rank.IntPart())
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+
+	fraction := rank.Sub(decimal.NewFromInt(int64(lower)))
+	return sorted[lower].Add(sorted[lower+1].Sub(sorted[lower]).Mul(fraction))
+}
+
+// topTransactions returns the n transactions with the largest amounts, earliest first on ties
+func topTransactions(transactions []Transaction, n int) []Transaction {
+	sorted := make([]Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Amount.Equal(sorted[j].Amount) {
+			return sorted[i].Amount.GreaterThan(sorted[j].Amount)
+		}
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -1,27 +1,69 @@
 package domain
 
 import (
+	"encoding/json"
+	"sort"
+	"time"
+
 	"github.com/shopspring/decimal"
 )
 
 type Summary struct {
-	TotalBalance        decimal.Decimal            `json:"total_balance"`
-	MonthlyTransactions map[string]int             `json:"monthly_transactions"`
-	MonthlyCredits      map[string]decimal.Decimal `json:"monthly_credits"`
-	MonthlyDebits       map[string]decimal.Decimal `json:"monthly_debits"`
-	AverageCredit       decimal.Decimal            `json:"average_credit"`
-	AverageDebit        decimal.Decimal            `json:"average_debit"`
+	// TotalBalance is the net movement of the summarized transactions
+	TotalBalance        decimal.Decimal               `json:"total_balance"`
+	MonthlyTransactions map[YearMonth]int             `json:"monthly_transactions"`
+	MonthlyCredits      map[YearMonth]decimal.Decimal `json:"monthly_credits"`
+	MonthlyDebits       map[YearMonth]decimal.Decimal `json:"monthly_debits"`
+	AverageCredit       decimal.Decimal               `json:"average_credit"`
+	AverageDebit        decimal.Decimal               `json:"average_debit"`
+	// RunningBalance is the end-of-day balance for every day with transactions, in date order
+	RunningBalance []BalancePoint `json:"running_balance"`
+
+	CreditStats     AmountStats   `json:"credit_stats"`
+	DebitStats      AmountStats   `json:"debit_stats"`
+	LargestCredit   *Transaction  `json:"largest_credit"`
+	LargestDebit    *Transaction  `json:"largest_debit"`
+	TopTransactions []Transaction `json:"top_transactions"`
+
+	// Comparison with the previous period, set when transaction history is available
+	Comparison *PeriodComparison `json:"comparison,omitempty"`
+	// AccountBalance is the persisted balance of the whole account, set when the database is enabled
+	AccountBalance *decimal.Decimal `json:"account_balance,omitempty"`
+}
+
+// MonthSummary holds the activity and balances of a single month
+type MonthSummary struct {
+	Month          YearMonth       `json:"month"`
+	Transactions   int             `json:"transactions"`
+	Credits        decimal.Decimal `json:"credits"`
+	Debits         decimal.Decimal `json:"debits"`
+	Net            decimal.Decimal `json:"net"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	ClosingBalance decimal.Decimal `json:"closing_balance"`
+}
+
+// BalancePoint is the balance at the end of a day
+type BalancePoint struct {
+	Date    time.Time       `json:"date"`
+	Balance decimal.Decimal `json:"balance"`
 }
 
 // NewSummary creates a new summary from a list of transactions
 func NewSummary(transactions []Transaction) *Summary {
+	return NewSummaryWithOptions(transactions, SummaryOptions{TopTransactions: DefaultTopTransactions})
+}
+
+// NewSummaryWithOptions creates a new summary from a list of transactions
+func NewSummaryWithOptions(transactions []Transaction, options SummaryOptions) *Summary {
 	summary := &Summary{
 		TotalBalance:        decimal.Zero,
-		MonthlyTransactions: make(map[string]int),
-		MonthlyCredits:      make(map[string]decimal.Decimal),
-		MonthlyDebits:       make(map[string]decimal.Decimal),
+		MonthlyTransactions: make(map[YearMonth]int),
+		MonthlyCredits:      make(map[YearMonth]decimal.Decimal),
+		MonthlyDebits:       make(map[YearMonth]decimal.Decimal),
 		AverageCredit:       decimal.Zero,
 		AverageDebit:        decimal.Zero,
+		RunningBalance:      []BalancePoint{},
+		TopTransactions:     []Transaction{},
 	}
 
 	if len(transactions) == 0 {
@@ -30,25 +72,35 @@ func NewSummary(transactions []Transaction) *Summary {
 
 	var totalCredits, totalDebits decimal.Decimal
 	var creditCount, debitCount int
+	var creditAmounts, debitAmounts []decimal.Decimal
 
 	for _, transaction := range transactions {
-		monthYear := transaction.MonthYear()
+		month := transaction.YearMonth()
 
-		summary.MonthlyTransactions[monthYear]++
+		summary.MonthlyTransactions[month]++
 
 		if transaction.IsCredit() {
 			summary.TotalBalance = summary.TotalBalance.Add(transaction.Amount)
-			summary.MonthlyCredits[monthYear] = summary.MonthlyCredits[monthYear].Add(transaction.Amount)
+			summary.MonthlyCredits[month] = summary.MonthlyCredits[month].Add(transaction.Amount)
 			totalCredits = totalCredits.Add(transaction.Amount)
 			creditCount++
+			creditAmounts = append(creditAmounts, transaction.Amount)
+			summary.LargestCredit = larger(summary.LargestCredit, transaction)
 		} else {
 			summary.TotalBalance = summary.TotalBalance.Sub(transaction.Amount)
-			summary.MonthlyDebits[monthYear] = summary.MonthlyDebits[monthYear].Add(transaction.Amount)
+			summary.MonthlyDebits[month] = summary.MonthlyDebits[month].Add(transaction.Amount)
 			totalDebits = totalDebits.Add(transaction.Amount)
 			debitCount++
+			debitAmounts = append(debitAmounts, transaction.Amount)
+			summary.LargestDebit = larger(summary.LargestDebit, transaction)
 		}
 	}
 
+	summary.RunningBalance = runningBalance(transactions)
+	summary.CreditStats = newAmountStats(creditAmounts)
+	summary.DebitStats = newAmountStats(debitAmounts)
+	summary.TopTransactions = topTransactions(transactions, options.TopTransactions)
+
 	if creditCount > 0 {
 		summary.AverageCredit = totalCredits.Div(decimal.NewFromInt(int64(creditCount)))
 	}
@@ -67,3 +119,90 @@ func (s *Summary) HasTransactions() bool {
 	}
 	return total > 0
 }
+
+// Months returns the months with activity in chronological order
+func (s *Summary) Months() []YearMonth {
+	months := make([]YearMonth, 0, len(s.MonthlyTransactions))
+	for month := range s.MonthlyTransactions {
+		months = append(months, month)
+	}
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Before(months[j])
+	})
+	return months
+}
+
+// Period returns the months covered by the summary, false when it has no transactions
+func (s *Summary) Period() (Period, bool) {
+	months := s.Months()
+	if len(months) == 0 {
+		return Period{}, false
+	}
+	return Period{From: months[0], To: months[len(months)-1]}, true
+}
+
+// Monthly returns the activity of every month in chronological order. Balances start
+// from zero before the first month, so the last closing balance equals TotalBalance
+func (s *Summary) Monthly() []MonthSummary {
+	months := s.Months()
+	monthly := make([]MonthSummary, len(months))
+	balance := decimal.Zero
+	for i, month := range months {
+		credits := s.MonthlyCredits[month]
+		debits := s.MonthlyDebits[month]
+		net := credits.Sub(debits)
+		monthly[i] = MonthSummary{
+			Month:          month,
+			Transactions:   s.MonthlyTransactions[month],
+			Credits:        credits,
+			Debits:         debits,
+			Net:            net,
+			OpeningBalance: balance,
+			ClosingBalance: balance.Add(net),
+		}
+		balance = balance.Add(net)
+	}
+	return monthly
+}
+
+// larger returns the transaction with the greater amount, keeping the current one on ties
+func larger(current *Transaction, candidate Transaction) *Transaction {
+	if current == nil || candidate.Amount.GreaterThan(current.Amount) {
+		return &candidate
+	}
+	return current
+}
+
+// runningBalance accumulates the transactions in date order, one point per day
+func runningBalance(transactions []Transaction) []BalancePoint {
+	sorted := make([]Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	points := []BalancePoint{}
+	balance := decimal.Zero
+	for _, transaction := range sorted {
+		balance = balance.Add(transaction.SignedAmount())
+		day := time.Date(transaction.Date.Year(), transaction.Date.Month(), transaction.Date.Day(), 0, 0, 0, 0, transaction.Date.Location())
+		if n := len(points); n > 0 && points[n-1].Date.Equal(day) {
+			points[n-1].Balance = balance
+			continue
+		}
+		points = append(points, BalancePoint{Date: day, Balance: balance})
+	}
+	return points
+}
+
+// MarshalJSON encodes the summary adding "months", the chronologically ordered monthly activity
+func (s Summary) MarshalJSON() ([]byte, error) {
+	type summaryFields Summary
+	return json.Marshal(struct {
+		summaryFields
+		Months []MonthSummary `json:"months"`
+	}{
+		summaryFields: summaryFields(s),
+		Months:        s.Monthly(),
+	})
+}
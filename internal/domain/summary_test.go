@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -26,12 +27,15 @@ func TestNewSummary(t *testing.T) {
 	expectedJuly := 3
 	expectedAugust := 2
 
-	if summary.MonthlyTransactions["July 2024"] != expectedJuly {
-		t.Errorf("July transactions = %d, want %d", summary.MonthlyTransactions["July 2024"], expectedJuly)
+	july := YearMonth{Year: 2024, Month: time.July}
+	august := YearMonth{Year: 2024, Month: time.August}
+
+	if summary.MonthlyTransactions[july] != expectedJuly {
+		t.Errorf("July transactions = %d, want %d", summary.MonthlyTransactions[july], expectedJuly)
 	}
 
-	if summary.MonthlyTransactions["August 2024"] != expectedAugust {
-		t.Errorf("August transactions = %d, want %d", summary.MonthlyTransactions["August 2024"], expectedAugust)
+	if summary.MonthlyTransactions[august] != expectedAugust {
+		t.Errorf("August transactions = %d, want %d", summary.MonthlyTransactions[august], expectedAugust)
 	}
 
 	// Credits: 60.5 + 10 + 15.25 = 85.75 / 3 = 28.583333...
@@ -127,3 +131,181 @@ func TestNewSummaryOnlyDebits(t *testing.T) {
 		t.Errorf("AverageDebit = %s, want %s", summary.AverageDebit.String(), expectedAvgDebit.String())
 	}
 }
+
+func TestSummaryMonthsChronological(t *testing.T) {
+	transactions := []Transaction{
+		{ID: 1, Date: time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("20"), Type: Debit},
+		{ID: 2, Date: time.Date(2023, 12, 30, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("50"), Type: Credit},
+		{ID: 3, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("10"), Type: Credit},
+		{ID: 4, Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("5"), Type: Debit},
+	}
+
+	summary := NewSummary(transactions)
+
+	want := []string{"2023-12", "2024-01", "2024-07", "2024-08"}
+	months := summary.Months()
+	if len(months) != len(want) {
+		t.Fatalf("Months() returned %d months, want %d", len(months), len(want))
+	}
+	for i, month := range months {
+		if month.String() != want[i] {
+			t.Errorf("Months()[%d] = %s, want %s", i, month, want[i])
+		}
+	}
+
+	monthly := summary.Monthly()
+	if !monthly[0].Credits.Equal(decimal.RequireFromString("50")) || !monthly[3].Debits.Equal(decimal.RequireFromString("20")) {
+		t.Errorf("Monthly() totals do not match their months: %+v", monthly)
+	}
+}
+
+func TestSummaryJSON(t *testing.T) {
+	summary := NewSummary([]Transaction{
+		{ID: 1, Date: time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("20"), Type: Debit},
+		{ID: 2, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("10"), Type: Credit},
+	})
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded struct {
+		MonthlyTransactions map[YearMonth]int `json:"monthly_transactions"`
+		Months              []MonthSummary    `json:"months"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if decoded.MonthlyTransactions[YearMonth{Year: 2024, Month: time.July}] != 1 {
+		t.Errorf("monthly_transactions not keyed by year-month: %s", data)
+	}
+	if len(decoded.Months) != 2 || decoded.Months[0].Month.String() != "2024-07" {
+		t.Errorf("months not in chronological order: %s", data)
+	}
+}
+
+func TestSummaryMonthlyBalances(t *testing.T) {
+	transactions := []Transaction{
+		{ID: 1, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("60.5"), Type: Credit},
+		{ID: 2, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("10.3"), Type: Debit},
+		{ID: 3, Date: time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("80"), Type: Debit},
+		{ID: 4, Date: time.Date(2024, 7, 28, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("15.25"), Type: Credit},
+	}
+
+	summary := NewSummary(transactions)
+	monthly := summary.Monthly()
+	if len(monthly) != 2 {
+		t.Fatalf("Monthly() returned %d months, want 2", len(monthly))
+	}
+
+	july, august := monthly[0], monthly[1]
+	if !july.Net.Equal(decimal.RequireFromString("65.45")) {
+		t.Errorf("July net = %s, want 65.45", july.Net)
+	}
+	if !july.OpeningBalance.IsZero() || !july.ClosingBalance.Equal(decimal.RequireFromString("65.45")) {
+		t.Errorf("July balances = %s -> %s, want 0 -> 65.45", july.OpeningBalance, july.ClosingBalance)
+	}
+	if !august.OpeningBalance.Equal(july.ClosingBalance) {
+		t.Errorf("August opening balance = %s, want %s", august.OpeningBalance, july.ClosingBalance)
+	}
+	if !august.ClosingBalance.Equal(summary.TotalBalance) {
+		t.Errorf("last closing balance = %s, want total balance %s", august.ClosingBalance, summary.TotalBalance)
+	}
+
+	want := []string{"50.2", "65.45", "-14.55"}
+	if len(summary.RunningBalance) != len(want) {
+		t.Fatalf("RunningBalance has %d points, want %d", len(summary.RunningBalance), len(want))
+	}
+	for i, point := range summary.RunningBalance {
+		if !point.Balance.Equal(decimal.RequireFromString(want[i])) {
+			t.Errorf("RunningBalance[%d] = %s on %s, want %s", i, point.Balance, point.Date.Format("2006-01-02"), want[i])
+		}
+	}
+}
+
+func TestSummaryStatistics(t *testing.T) {
+	transactions := []Transaction{
+		{ID: 1, Date: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("10"), Type: Credit},
+		{ID: 2, Date: time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("20"), Type: Credit},
+		{ID: 3, Date: time.Date(2024, 7, 3, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("30"), Type: Credit},
+		{ID: 4, Date: time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("1000"), Type: Credit},
+		{ID: 5, Date: time.Date(2024, 7, 5, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("5"), Type: Debit},
+		{ID: 6, Date: time.Date(2024, 7, 6, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("45"), Type: Debit},
+	}
+
+	summary := NewSummaryWithOptions(transactions, SummaryOptions{TopTransactions: 3})
+
+	credits := summary.CreditStats
+	if credits.Count != 4 || summary.DebitStats.Count != 2 {
+		t.Errorf("counts = %d credits, %d debits, want 4 and 2", credits.Count, summary.DebitStats.Count)
+	}
+
+	checks := []struct {
+		name string
+		got  decimal.Decimal
+		want string
+	}{
+		{"credit median", credits.Median, "25"},
+		{"credit p25", credits.P25, "17.5"},
+		{"credit p90", credits.P90, "709"},
+		{"credit min", credits.Min, "10"},
+		{"credit max", credits.Max, "1000"},
+		{"debit median", summary.DebitStats.Median, "25"},
+	}
+	for _, c := range checks {
+		if !c.got.Equal(decimal.RequireFromString(c.want)) {
+			t.Errorf("%s = %s, want %s", c.name, c.got, c.want)
+		}
+	}
+
+	if summary.LargestCredit == nil || summary.LargestCredit.ID != 4 {
+		t.Errorf("LargestCredit = %+v, want transaction 4", summary.LargestCredit)
+	}
+	if summary.LargestDebit == nil || summary.LargestDebit.ID != 6 {
+		t.Errorf("LargestDebit = %+v, want transaction 6", summary.LargestDebit)
+	}
+
+	var top []int
+	for _, transaction := range summary.TopTransactions {
+		top = append(top, transaction.ID)
+	}
+	if len(top) != 3 || top[0] != 4 || top[1] != 6 || top[2] != 3 {
+		t.Errorf("TopTransactions = %v, want [4 6 3]", top)
+	}
+}
+
+func TestPeriodComparison(t *testing.T) {
+	summary := NewSummary([]Transaction{
+		{ID: 1, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("150"), Type: Credit},
+		{ID: 2, Date: time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("50"), Type: Debit},
+	})
+
+	period, ok := summary.Period()
+	if !ok {
+		t.Fatal("Period() reported no transactions")
+	}
+	previous := period.Previous()
+	if previous.From.String() != "2024-05" || previous.To.String() != "2024-06" {
+		t.Errorf("Previous() = %s..%s, want 2024-05..2024-06", previous.From, previous.To)
+	}
+	if end := previous.To.End(); end.Day() != 30 {
+		t.Errorf("June ends on day %d, want 30", end.Day())
+	}
+
+	comparison := NewPeriodComparison(summary, []Transaction{
+		{ID: 3, Date: time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("100"), Type: Credit},
+	})
+
+	if !comparison.Income.Delta.Equal(decimal.RequireFromString("50")) || comparison.Income.Percent == nil ||
+		!comparison.Income.Percent.Equal(decimal.RequireFromString("50")) {
+		t.Errorf("Income = %+v, want +50 (+50%%)", comparison.Income)
+	}
+	if comparison.Spend.Percent != nil {
+		t.Errorf("Spend percent = %s, want nil without previous spend", comparison.Spend.Percent)
+	}
+	if !comparison.Net.Current.Equal(decimal.RequireFromString("100")) || !comparison.Net.Delta.IsZero() {
+		t.Errorf("Net = %+v, want 100 with no change", comparison.Net)
+	}
+}
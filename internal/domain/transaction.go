@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"hash/fnv"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +33,12 @@ type Transaction struct {
 	Date   time.Time       `json:"date"`
 	Amount decimal.Decimal `json:"amount"`
 	Type   TransactionType `json:"type"`
+	// Reference is the identifier assigned by the bank (e.g. an OFX FITID), empty for CSV input
+	Reference string `json:"reference,omitempty"`
+	// Description is the bank's payee or memo text, empty for CSV input
+	Description string `json:"description,omitempty"`
+	// Currency is the ISO 4217 code given by the bank, empty when the input does not state it
+	Currency string `json:"currency,omitempty"`
 }
 
 // NewTransaction creates a new transaction from CSV data
@@ -58,6 +66,20 @@ func NewTransaction(id, date, amount string) (*Transaction, error) {
 	}, nil
 }
 
+// TransactionIDFromReference derives a transaction ID from a bank reference: numeric
+// references are used as they are, any other is hashed into the positive 32-bit range.
+// Unrelated references may share an ID, so such transactions are identified by their
+// reference, never by the derived ID
+func TransactionIDFromReference(reference string) int {
+	if id, err := strconv.Atoi(reference); err == nil && id >= 0 && id <= math.MaxInt32 {
+		return id
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(reference))
+	return int(hash.Sum32() & math.MaxInt32)
+}
+
 // IsCredit returns true if the transaction is a credit
 func (t *Transaction) IsCredit() bool {
 	return t.Type == Credit
@@ -73,9 +95,17 @@ func (t *Transaction) AbsoluteAmount() decimal.Decimal {
 	return t.Amount.Abs()
 }
 
-// MonthYear returns the month and year as a string (e.g., "July 2024")
-func (t *Transaction) MonthYear() string {
-	return t.Date.Format("January 2006")
+// SignedAmount returns the amount as it affects the balance: positive for credits, negative for debits
+func (t *Transaction) SignedAmount() decimal.Decimal {
+	if t.IsDebit() {
+		return t.Amount.Neg()
+	}
+	return t.Amount
+}
+
+// YearMonth returns the calendar month of the transaction
+func (t *Transaction) YearMonth() YearMonth {
+	return NewYearMonth(t.Date)
 }
 
 func parseDate(dateStr string) (time.Time, error) {
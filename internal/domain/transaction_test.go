@@ -129,10 +129,9 @@ func TestTransactionMethods(t *testing.T) {
 		t.Errorf("AbsoluteAmount() = %s, want %s", debitTx.AbsoluteAmount().String(), expectedAmount.String())
 	}
 
-	currentYear := time.Now().Year()
-	expectedMonthYear := "July " + string(rune(currentYear/1000)+'0') + string(rune((currentYear/100)%10)+'0') + string(rune((currentYear/10)%10)+'0') + string(rune(currentYear%10)+'0')
-	if creditTx.MonthYear() != expectedMonthYear {
-		t.Errorf("MonthYear() = %s, want %s", creditTx.MonthYear(), expectedMonthYear)
+	expectedMonth := YearMonth{Year: time.Now().Year(), Month: time.July}
+	if creditTx.YearMonth() != expectedMonth {
+		t.Errorf("YearMonth() = %s, want %s", creditTx.YearMonth(), expectedMonth)
 	}
 }
 
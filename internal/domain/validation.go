@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Validation issue severities
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// ValidationIssue is a problem found in the transactions of a file
+type ValidationIssue struct {
+	Severity string `json:"severity"`
+	// TransactionID is the transaction at fault, zero for issues of the whole file
+	TransactionID int    `json:"transaction_id,omitempty"`
+	Message       string `json:"message"`
+}
+
+// ValidationReport describes the transactions read from a file and the issues found in them
+type ValidationReport struct {
+	Transactions int       `json:"transactions"`
+	Credits      int       `json:"credits"`
+	Debits       int       `json:"debits"`
+	FirstDate    time.Time `json:"first_date,omitzero"`
+	LastDate     time.Time `json:"last_date,omitzero"`
+	// Currencies are the currencies stated by the file, empty when it states none
+	Currencies []string          `json:"currencies,omitempty"`
+	Issues     []ValidationIssue `json:"issues"`
+}
+
+// Valid reports whether the report holds no errors; warnings are allowed
+func (r ValidationReport) Valid() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateTransactions checks transactions for what would be stored or summarized
+// wrongly: duplicate IDs, zero amounts, dates after now and mixed currencies
+func ValidateTransactions(transactions []Transaction, now time.Time) ValidationReport {
+	report := ValidationReport{
+		Transactions: len(transactions),
+		Issues:       []ValidationIssue{},
+	}
+	if len(transactions) == 0 {
+		report.Issues = append(report.Issues, ValidationIssue{Severity: SeverityError, Message: "no transactions found"})
+		return report
+	}
+
+	seen := make(map[int]bool, len(transactions))
+	currencies := make(map[string]bool)
+	for _, transaction := range transactions {
+		if transaction.Type == Credit {
+			report.Credits++
+		} else {
+			report.Debits++
+		}
+		if report.FirstDate.IsZero() || transaction.Date.Before(report.FirstDate) {
+			report.FirstDate = transaction.Date
+		}
+		if transaction.Date.After(report.LastDate) {
+			report.LastDate = transaction.Date
+		}
+		if transaction.Currency != "" {
+			currencies[transaction.Currency] = true
+		}
+
+		if seen[transaction.ID] {
+			report.Issues = append(report.Issues, ValidationIssue{
+				Severity:      SeverityError,
+				TransactionID: transaction.ID,
+				Message:       "duplicate transaction ID; only one of them would be stored",
+			})
+		}
+		seen[transaction.ID] = true
+
+		if transaction.Amount.IsZero() {
+			report.Issues = append(report.Issues, ValidationIssue{
+				Severity:      SeverityWarning,
+				TransactionID: transaction.ID,
+				Message:       "zero amount",
+			})
+		}
+		if transaction.Date.After(now) {
+			report.Issues = append(report.Issues, ValidationIssue{
+				Severity:      SeverityWarning,
+				TransactionID: transaction.ID,
+				Message:       fmt.Sprintf("dated in the future (%s)", transaction.Date.Format("2006-01-02")),
+			})
+		}
+	}
+
+	for currency := range currencies {
+		report.Currencies = append(report.Currencies, currency)
+	}
+	sort.Strings(report.Currencies)
+	if len(report.Currencies) > 1 {
+		report.Issues = append(report.Issues, ValidationIssue{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("mixed currencies %v are summarized together", report.Currencies),
+		})
+	}
+	return report
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// YearMonth identifies a calendar month. It orders chronologically and
+// serializes as "2006-01"; human formatting is left to the presentation layer
+type YearMonth struct {
+	Year  int
+	Month time.Month
+}
+
+const yearMonthLayout = "2006-01"
+
+// NewYearMonth returns the calendar month containing t
+func NewYearMonth(t time.Time) YearMonth {
+	return YearMonth{Year: t.Year(), Month: t.Month()}
+}
+
+// ParseYearMonth parses a "2006-01" formatted month
+func ParseYearMonth(value string) (YearMonth, error) {
+	t, err := time.Parse(yearMonthLayout, value)
+	if err != nil {
+		return YearMonth{}, fmt.Errorf("invalid year-month %q: %w", value, err)
+	}
+	return NewYearMonth(t), nil
+}
+
+// String returns the month formatted as "2006-01"
+func (ym YearMonth) String() string {
+	return fmt.Sprintf("%04d-%02d", ym.Year, int(ym.Month))
+}
+
+// Before returns true if ym is an earlier month than other
+func (ym YearMonth) Before(other YearMonth) bool {
+	if ym.Year != other.Year {
+		return ym.Year < other.Year
+	}
+	return ym.Month < other.Month
+}
+
+// Start returns the first instant of the month in UTC
+func (ym YearMonth) Start() time.Time {
+	return time.Date(ym.Year, ym.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// MarshalText implements encoding.TextMarshaler, so months can key JSON objects
+func (ym YearMonth) MarshalText() ([]byte, error) {
+	return []byte(ym.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (ym *YearMonth) UnmarshalText(text []byte) error {
+	parsed, err := ParseYearMonth(string(text))
+	if err != nil {
+		return err
+	}
+	*ym = parsed
+	return nil
+}
+
+// AddMonths returns the month n months after ym, or before it when n is negative
+func (ym YearMonth) AddMonths(n int) YearMonth {
+	return NewYearMonth(ym.Start().AddDate(0, n, 0))
+}
+
+// End returns the last day of the month in UTC
+func (ym YearMonth) End() time.Time {
+	return ym.AddMonths(1).Start().AddDate(0, 0, -1)
+}
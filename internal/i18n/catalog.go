@@ -0,0 +1,179 @@
+package i18n
+
+// catalogs holds the translations of every user facing string, keyed by message ID
+var catalogs = map[Locale]map[string]string{
+	English: {
+		"format.month_year": "%s %d",
+		"format.date":       "%[1]s %[2]d, %[3]d",
+
+		"email.subject":              "Stori - Your Account Summary",
+		"email.html_title":           "Your Storicard Monthly Snapshot",
+		"email.logo_alt":             "Storicard Logo",
+		"email.title":                "Your Monthly Stori Snapshot",
+		"email.greeting":             "Hi! Here's a summary of your account activity this month.",
+		"email.balance_label":        "Your Current Balance",
+		"email.net_movement_label":   "This file's net movement",
+		"email.monthly_overview":     "📈 Monthly Transactions Overview",
+		"email.transactions_in":      "Transactions in %s:",
+		"email.monthly_balances":     "🗓️ Monthly Balances",
+		"email.chart_alt":            "Monthly credits, debits and closing balance",
+		"email.column.month":         "Month",
+		"email.column.credits":       "Credits",
+		"email.column.debits":        "Debits",
+		"email.column.net":           "Net",
+		"email.column.closing":       "Closing balance",
+		"email.comparison":           "⚖️ Compared with %s",
+		"email.comparison.income":    "Income:",
+		"email.comparison.spend":     "Spending:",
+		"email.comparison.net":       "Net balance change:",
+		"email.averages":             "📊 Average Transaction Values",
+		"email.average_credit":       "Average credit:",
+		"email.average_debit":        "Average debit:",
+		"email.statistics":           "🔎 Transaction Statistics",
+		"email.stat.count":           "Transactions",
+		"email.stat.median":          "Median",
+		"email.stat.p90":             "90th percentile",
+		"email.stat.largest":         "Largest",
+		"email.top_transactions":     "Largest transactions",
+		"email.no_credits_or_debits": "No credit or debit transactions this month.",
+		"email.no_transactions":      "🌱 Looks like there were no transactions for your account in the processed file this month.",
+		"email.footer.thanks":        "Thank you for being a Storicard member!",
+		"email.footer.questions":     `Have questions? Visit our <a href="https://www.storicard.com/faq">FAQ</a> or <a href="https://www.storicard.com/contact">contact support</a>.`,
+		"email.footer.automated":     "This is an automated message. Please do not reply directly to this email.",
+		"email.footer.copyright":     "© %d Storicard. All rights reserved.",
+
+		"statement.title":              "Account Statement",
+		"statement.account":            "Account: %s",
+		"statement.period":             "Period: %s",
+		"statement.generated":          "Generated on %s",
+		"statement.opening_balance":    "Opening balance",
+		"statement.closing_balance":    "Closing balance",
+		"statement.total_credits":      "Total credits",
+		"statement.total_debits":       "Total debits",
+		"statement.transactions":       "Transactions",
+		"statement.monthly_totals":     "Monthly totals",
+		"statement.column.date":        "Date",
+		"statement.column.id":          "Id",
+		"statement.column.description": "Description",
+		"statement.column.amount":      "Amount",
+		"statement.column.balance":     "Balance",
+		"statement.no_transactions":    "No transactions in this period.",
+		"statement.page":               "Page %d of %d",
+	},
+	Spanish: {
+		"format.month_year": "%s de %d",
+		"format.date":       "%[2]d de %[1]s de %[3]d",
+
+		"email.subject":              "Stori - El resumen de tu cuenta",
+		"email.html_title":           "Tu resumen mensual de Storicard",
+		"email.logo_alt":             "Logo de Storicard",
+		"email.title":                "Tu resumen mensual de Stori",
+		"email.greeting":             "¡Hola! Este es el resumen de la actividad de tu cuenta de este mes.",
+		"email.balance_label":        "Tu saldo actual",
+		"email.net_movement_label":   "Movimiento neto de este archivo",
+		"email.monthly_overview":     "📈 Resumen mensual de transacciones",
+		"email.transactions_in":      "Transacciones en %s:",
+		"email.monthly_balances":     "🗓️ Saldos mensuales",
+		"email.chart_alt":            "Créditos, débitos y saldo al cierre por mes",
+		"email.column.month":         "Mes",
+		"email.column.credits":       "Créditos",
+		"email.column.debits":        "Débitos",
+		"email.column.net":           "Neto",
+		"email.column.closing":       "Saldo al cierre",
+		"email.comparison":           "⚖️ Comparado con %s",
+		"email.comparison.income":    "Ingresos:",
+		"email.comparison.spend":     "Gastos:",
+		"email.comparison.net":       "Variación neta del saldo:",
+		"email.averages":             "📊 Valores promedio de las transacciones",
+		"email.average_credit":       "Crédito promedio:",
+		"email.average_debit":        "Débito promedio:",
+		"email.statistics":           "🔎 Estadísticas de transacciones",
+		"email.stat.count":           "Transacciones",
+		"email.stat.median":          "Mediana",
+		"email.stat.p90":             "Percentil 90",
+		"email.stat.largest":         "Mayor",
+		"email.top_transactions":     "Transacciones más grandes",
+		"email.no_credits_or_debits": "No hubo créditos ni débitos este mes.",
+		"email.no_transactions":      "🌱 Parece que no hubo transacciones de tu cuenta en el archivo procesado este mes.",
+		"email.footer.thanks":        "¡Gracias por ser parte de Storicard!",
+		"email.footer.questions":     `¿Tienes preguntas? Visita nuestras <a href="https://www.storicard.com/faq">preguntas frecuentes</a> o <a href="https://www.storicard.com/contact">contacta a soporte</a>.`,
+		"email.footer.automated":     "Este es un mensaje automático. Por favor no respondas directamente a este correo.",
+		"email.footer.copyright":     "© %d Storicard. Todos los derechos reservados.",
+
+		"statement.title":              "Estado de cuenta",
+		"statement.account":            "Cuenta: %s",
+		"statement.period":             "Período: %s",
+		"statement.generated":          "Generado el %s",
+		"statement.opening_balance":    "Saldo inicial",
+		"statement.closing_balance":    "Saldo final",
+		"statement.total_credits":      "Total de créditos",
+		"statement.total_debits":       "Total de débitos",
+		"statement.transactions":       "Transacciones",
+		"statement.monthly_totals":     "Totales mensuales",
+		"statement.column.date":        "Fecha",
+		"statement.column.id":          "Id",
+		"statement.column.description": "Descripción",
+		"statement.column.amount":      "Importe",
+		"statement.column.balance":     "Saldo",
+		"statement.no_transactions":    "No hay transacciones en este período.",
+		"statement.page":               "Página %d de %d",
+	},
+	Portuguese: {
+		"format.month_year": "%s de %d",
+		"format.date":       "%[2]d de %[1]s de %[3]d",
+
+		"email.subject":              "Stori - O resumo da sua conta",
+		"email.html_title":           "Seu resumo mensal Storicard",
+		"email.logo_alt":             "Logo da Storicard",
+		"email.title":                "Seu resumo mensal Stori",
+		"email.greeting":             "Olá! Aqui está o resumo da atividade da sua conta neste mês.",
+		"email.balance_label":        "Seu saldo atual",
+		"email.net_movement_label":   "Movimentação líquida deste arquivo",
+		"email.monthly_overview":     "📈 Visão mensal das transações",
+		"email.transactions_in":      "Transações em %s:",
+		"email.monthly_balances":     "🗓️ Saldos mensais",
+		"email.chart_alt":            "Créditos, débitos e saldo de fechamento por mês",
+		"email.column.month":         "Mês",
+		"email.column.credits":       "Créditos",
+		"email.column.debits":        "Débitos",
+		"email.column.net":           "Líquido",
+		"email.column.closing":       "Saldo de fechamento",
+		"email.comparison":           "⚖️ Comparado com %s",
+		"email.comparison.income":    "Receitas:",
+		"email.comparison.spend":     "Gastos:",
+		"email.comparison.net":       "Variação líquida do saldo:",
+		"email.averages":             "📊 Valores médios das transações",
+		"email.average_credit":       "Crédito médio:",
+		"email.average_debit":        "Débito médio:",
+		"email.statistics":           "🔎 Estatísticas das transações",
+		"email.stat.count":           "Transações",
+		"email.stat.median":          "Mediana",
+		"email.stat.p90":             "Percentil 90",
+		"email.stat.largest":         "Maior",
+		"email.top_transactions":     "Maiores transações",
+		"email.no_credits_or_debits": "Nenhum crédito ou débito neste mês.",
+		"email.no_transactions":      "🌱 Parece que não houve transações da sua conta no arquivo processado neste mês.",
+		"email.footer.thanks":        "Obrigado por ser membro Storicard!",
+		"email.footer.questions":     `Tem dúvidas? Visite nossas <a href="https://www.storicard.com/faq">perguntas frequentes</a> ou <a href="https://www.storicard.com/contact">fale com o suporte</a>.`,
+		"email.footer.automated":     "Esta é uma mensagem automática. Por favor, não responda diretamente a este e-mail.",
+		"email.footer.copyright":     "© %d Storicard. Todos os direitos reservados.",
+
+		"statement.title":              "Extrato da conta",
+		"statement.account":            "Conta: %s",
+		"statement.period":             "Período: %s",
+		"statement.generated":          "Gerado em %s",
+		"statement.opening_balance":    "Saldo inicial",
+		"statement.closing_balance":    "Saldo final",
+		"statement.total_credits":      "Total de créditos",
+		"statement.total_debits":       "Total de débitos",
+		"statement.transactions":       "Transações",
+		"statement.monthly_totals":     "Totais mensais",
+		"statement.column.date":        "Data",
+		"statement.column.id":          "Id",
+		"statement.column.description": "Descrição",
+		"statement.column.amount":      "Valor",
+		"statement.column.balance":     "Saldo",
+		"statement.no_transactions":    "Nenhuma transação neste período.",
+		"statement.page":               "Página %d de %d",
+	},
+}
@@ -0,0 +1,141 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Locale string
+
+const (
+	English    Locale = "en"
+	Spanish    Locale = "es"
+	Portuguese Locale = "pt"
+)
+
+// DefaultLocale is used when no locale is configured for an account
+const DefaultLocale = English
+
+// Supported returns every locale with a translation catalog
+func Supported() []Locale {
+	return []Locale{English, Spanish, Portuguese}
+}
+
+// Parse parses a locale tag such as "es", "es-AR" or "pt_BR" into a supported locale
+func Parse(tag string) (Locale, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if base, _, found := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-"); found {
+		tag = base
+	}
+
+	for _, locale := range Supported() {
+		if string(locale) == tag {
+			return locale, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported locale %q", tag)
+}
+
+// ParseAccountLocales parses per-account locales in the form "alice@example.com=es;bob@example.com=pt"
+func ParseAccountLocales(value string) (map[string]Locale, error) {
+	locales := make(map[string]Locale)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		account, tag, found := strings.Cut(entry, "=")
+		if !found || strings.TrimSpace(account) == "" {
+			return nil, fmt.Errorf("invalid account locale entry %q", entry)
+		}
+
+		locale, err := Parse(tag)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", account, err)
+		}
+		locales[strings.ToLower(strings.TrimSpace(account))] = locale
+	}
+	return locales, nil
+}
+
+// T returns the translation for key, formatted with args. Missing translations
+// fall back to English, then to the key itself
+func (l Locale) T(key string, args ...any) string {
+	message, ok := catalogs[l][key]
+	if !ok {
+		message, ok = catalogs[English][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// MonthName returns the localized name of a month
+func (l Locale) MonthName(month time.Month) string {
+	names, ok := monthNames[l]
+	if !ok {
+		names = monthNames[English]
+	}
+	return names[month-1]
+}
+
+// FormatMonthYear formats a month and year, e.g. "July 2024", "julio de 2024" or "julho de 2024"
+func (l Locale) FormatMonthYear(year int, month time.Month) string {
+	return l.T("format.month_year", l.MonthName(month), year)
+}
+
+// FormatDate formats a calendar date, e.g. "July 15, 2024" or "15 de julio de 2024"
+func (l Locale) FormatDate(t time.Time) string {
+	return l.T("format.date", l.MonthName(t.Month()), t.Day(), t.Year())
+}
+
+// FormatNumber formats a decimal with a fixed number of places and the locale's
+// grouping and decimal separators, e.g. "1,234.50" or "1.234,50"
+func (l Locale) FormatNumber(value decimal.Decimal, places int32) string {
+	group, point := ",", "."
+	if l == Spanish || l == Portuguese {
+		group, point = ".", ","
+	}
+
+	fixed := value.Abs().StringFixed(places)
+	integer, fraction, _ := strings.Cut(fixed, ".")
+
+	var b strings.Builder
+	if value.IsNegative() && !value.Abs().Round(places).IsZero() {
+		b.WriteByte('-')
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(group)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(point)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// FormatCurrency formats an amount with two decimals and the currency symbol, e.g. "$1,234.50"
+func (l Locale) FormatCurrency(value decimal.Decimal) string {
+	if value.IsNegative() {
+		return "-$" + l.FormatNumber(value.Abs(), 2)
+	}
+	return "$" + l.FormatNumber(value, 2)
+}
+
+var monthNames = map[Locale][12]string{
+	English:    {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	Spanish:    {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	Portuguese: {"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+}
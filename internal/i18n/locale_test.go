@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		locale Locale
+		value  string
+		want   string
+	}{
+		{English, "1234567.891", "1,234,567.89"},
+		{Spanish, "1234567.891", "1.234.567,89"},
+		{Portuguese, "1234.5", "1.234,50"},
+		{English, "999.999", "1,000.00"},
+		{English, "-54.99", "-54.99"},
+		{Spanish, "0", "0,00"},
+		{English, "-0.001", "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.locale.FormatNumber(decimal.RequireFromString(tt.value), 2); got != tt.want {
+			t.Errorf("%s.FormatNumber(%s) = %s, want %s", tt.locale, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMonthYear(t *testing.T) {
+	tests := []struct {
+		locale Locale
+		want   string
+	}{
+		{English, "July 2024"},
+		{Spanish, "julio de 2024"},
+		{Portuguese, "julho de 2024"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.locale.FormatMonthYear(2024, time.July); got != tt.want {
+			t.Errorf("%s.FormatMonthYear = %s, want %s", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2024, time.July, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		locale Locale
+		want   string
+	}{
+		{English, "July 15, 2024"},
+		{Spanish, "15 de julio de 2024"},
+		{Portuguese, "15 de julho de 2024"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.locale.FormatDate(date); got != tt.want {
+			t.Errorf("%s.FormatDate = %s, want %s", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    Locale
+		wantErr bool
+	}{
+		{tag: "es", want: Spanish},
+		{tag: "es-AR", want: Spanish},
+		{tag: "pt_BR", want: Portuguese},
+		{tag: "EN", want: English},
+		{tag: "fr", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.tag)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%s) expected error", tt.tag)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%s) = %s, %v, want %s", tt.tag, got, err, tt.want)
+		}
+	}
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for key := range catalogs[English] {
+		for _, locale := range Supported() {
+			if _, ok := catalogs[locale][key]; !ok {
+				t.Errorf("locale %s is missing translation %s", locale, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// Chart size in pixels. Emails scale the image down to the available width
+const (
+	Width  = 560
+	Height = 240
+)
+
+const padding = 16
+
+// Colors matching the email styles
+var (
+	creditColor  = color.RGBA{0x1A, 0x7A, 0x7A, 0xFF}
+	debitColor   = color.RGBA{0xD3, 0x2F, 0x2F, 0xFF}
+	balanceColor = color.RGBA{0x0E, 0x58, 0x58, 0xFF}
+	axisColor    = color.RGBA{0xBD, 0xBD, 0xBD, 0xFF}
+	background   = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+// MonthlyActivity draws the monthly credits and debits as paired bars with the closing
+// balance trend on top, one slot per month in chronological order. It returns nil when
+// the summary has no transactions
+func MonthlyActivity(summary *domain.Summary) ([]byte, error) {
+	monthly := summary.Monthly()
+	if len(monthly) == 0 {
+		return nil, nil
+	}
+
+	high, low := decimal.Zero, decimal.Zero
+	for _, month := range monthly {
+		high = decimal.Max(high, month.Credits, month.Debits, month.ClosingBalance)
+		low = decimal.Min(low, month.ClosingBalance)
+	}
+	span, _ := high.Sub(low).Float64()
+	if span == 0 {
+		span = 1
+	}
+
+	top, bottom := float64(padding), float64(Height-padding)
+	lowValue, _ := low.Float64()
+	y := func(value decimal.Decimal) int {
+		v, _ := value.Float64()
+		return int(bottom - (v-lowValue)/span*(bottom-top) + 0.5)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	fillRect(img, 0, 0, Width, Height, background)
+
+	zero := y(decimal.Zero)
+	fillRect(img, padding, zero, Width-padding, zero+1, axisColor)
+
+	slot := float64(Width-2*padding) / float64(len(monthly))
+	barWidth := int(slot * 0.3)
+	if barWidth < 1 {
+		barWidth = 1
+	}
+
+	points := make([]image.Point, len(monthly))
+	for i, month := range monthly {
+		center := padding + int(slot*float64(i)+slot/2)
+		fillRect(img, center-barWidth, y(month.Credits), center, zero, creditColor)
+		fillRect(img, center, y(month.Debits), center+barWidth, zero, debitColor)
+		points[i] = image.Point{X: center, Y: y(month.ClosingBalance)}
+	}
+
+	for i := 1; i < len(points); i++ {
+		drawLine(img, points[i-1], points[i], 2, balanceColor)
+	}
+	for _, point := range points {
+		fillDisc(img, point, 4, balanceColor)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encoding chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fillRect fills the rectangle between two corners, in any order
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	rect := image.Rect(x0, y0, x1, y1).Intersect(img.Bounds())
+	for py := rect.Min.Y; py < rect.Max.Y; py++ {
+		for px := rect.Min.X; px < rect.Max.X; px++ {
+			img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+// fillDisc fills a circle of the given radius
+func fillDisc(img *image.RGBA, center image.Point, radius int, c color.RGBA) {
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius {
+				if p := center.Add(image.Pt(dx, dy)); p.In(img.Bounds()) {
+					img.SetRGBA(p.X, p.Y, c)
+				}
+			}
+		}
+	}
+}
+
+// drawLine strokes a straight line by stamping discs along it
+func drawLine(img *image.RGBA, from, to image.Point, radius int, c color.RGBA) {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	steps := max(abs(dx), abs(dy), 1)
+	for i := 0; i <= steps; i++ {
+		x := from.X + dx*i/steps
+		y := from.Y + dy*i/steps
+		fillDisc(img, image.Pt(x, y), radius, c)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,41 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func TestMonthlyActivity(t *testing.T) {
+	summary := domain.NewSummary([]domain.Transaction{
+		{ID: 1, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("60.5"), Type: domain.Credit},
+		{ID: 2, Date: time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("120"), Type: domain.Debit},
+	})
+
+	data, err := MonthlyActivity(summary)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("chart is not a valid PNG: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != Width || bounds.Dy() != Height {
+		t.Errorf("chart size = %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), Width, Height)
+	}
+}
+
+func TestMonthlyActivityEmpty(t *testing.T) {
+	data, err := MonthlyActivity(domain.NewSummary(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data != nil {
+		t.Error("expected no chart for a summary without transactions")
+	}
+}
@@ -0,0 +1,10 @@
+-- Tracks scheduled statements so each account receives each period exactly once.
+-- A run is claimed as 'sending' before delivery and marked 'sent' afterwards
+CREATE TABLE IF NOT EXISTS statement_runs (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    period CHAR(7) NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('sending', 'sent')),
+    claimed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP,
+    PRIMARY KEY (account_id, period)
+);
@@ -0,0 +1,3 @@
+-- Bank statements (e.g. OFX) identify transactions by their own reference and carry a description
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reference VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- ISO 4217 currency of the transaction, empty when the input file does not state it
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT '';
@@ -0,0 +1,28 @@
+-- Records each processed file unit, so its summary can be resent and quarantined files found
+CREATE TABLE IF NOT EXISTS processing_runs (
+    id UUID PRIMARY KEY,
+    source TEXT NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    account_id UUID REFERENCES accounts(id),
+    status VARCHAR(12) NOT NULL CHECK (status IN ('sent', 'failed', 'quarantined')),
+    error TEXT NOT NULL DEFAULT '',
+    transaction_ids INTEGER[] NOT NULL DEFAULT '{}',
+    quarantine_path TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The other members of a quarantined archive were already sent, so only these are reprocessed
+ALTER TABLE processing_runs ADD COLUMN IF NOT EXISTS failed_members TEXT[] NOT NULL DEFAULT '{}';
+
+-- The reference of each of transaction_ids: IDs derived from references are not unique
+ALTER TABLE processing_runs ADD COLUMN IF NOT EXISTS transaction_references TEXT[] NOT NULL DEFAULT '{}';
+
+-- Audit trail of administrative actions: resends, recomputes and reprocessing
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(20) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    target TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
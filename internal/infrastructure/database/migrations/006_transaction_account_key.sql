@@ -0,0 +1,11 @@
+-- Transaction IDs come from the input files, so they only identify a transaction within
+-- its account: a global key silently dropped the transactions of an account whose IDs
+-- another account had already used
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'transactions'::regclass AND conname = 'transactions_pkey') THEN
+        ALTER TABLE transactions DROP CONSTRAINT transactions_pkey;
+        ALTER TABLE transactions ALTER COLUMN account_id SET NOT NULL;
+        ALTER TABLE transactions ADD CONSTRAINT transactions_account_pkey PRIMARY KEY (account_id, id);
+    END IF;
+END $$;
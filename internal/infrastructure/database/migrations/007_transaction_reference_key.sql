@@ -0,0 +1,17 @@
+-- Bank transactions are identified by their reference, and their ID is only derived from
+-- it, so two references of an account may share an ID. Rows get their own key; bank
+-- transactions are unique by reference within the account, the others by ID
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS row_id BIGSERIAL;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'transactions'::regclass AND conname = 'transactions_account_pkey') THEN
+        ALTER TABLE transactions DROP CONSTRAINT transactions_account_pkey;
+        ALTER TABLE transactions ADD CONSTRAINT transactions_row_pkey PRIMARY KEY (row_id);
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_account_id_key
+    ON transactions (account_id, id) WHERE reference = '';
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_account_reference_key
+    ON transactions (account_id, reference) WHERE reference <> '';
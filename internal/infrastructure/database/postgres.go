@@ -6,6 +6,7 @@ import (
 	"embed"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/NahuelDT/stori-challenge/internal/domain"
 	"github.com/NahuelDT/stori-challenge/internal/infrastructure/database/repository"
@@ -30,6 +31,8 @@ type postgresDataStore struct {
 	db              *sql.DB
 	accountRepo     *repository.AccountRepository
 	transactionRepo *repository.TransactionRepository
+	statementRepo   *repository.StatementRunRepository
+	runRepo         *repository.ProcessingRunRepository
 	logger          *slog.Logger
 }
 
@@ -57,11 +60,15 @@ func NewPostgresDataStore(config PostgresConfig, logger *slog.Logger) (services.
 
 	accountRepo := repository.NewAccountRepository(db, queryLoader, logger)
 	transactionRepo := repository.NewTransactionRepository(db, queryLoader, logger)
+	statementRepo := repository.NewStatementRunRepository(db, queryLoader, logger)
+	runRepo := repository.NewProcessingRunRepository(db, queryLoader, logger)
 
 	store := &postgresDataStore{
 		db:              db,
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
+		statementRepo:   statementRepo,
+		runRepo:         runRepo,
 		logger:          logger,
 	}
 
@@ -74,41 +81,117 @@ func NewPostgresDataStore(config PostgresConfig, logger *slog.Logger) (services.
 	return store, nil
 }
 
-// SaveTransactions saves transactions to the database
-func (p *postgresDataStore) SaveTransactions(ctx context.Context, transactions []domain.Transaction) error {
-	if len(transactions) == 0 {
-		return nil
+// SaveTransactions saves transactions to the database under the given account
+func (p *postgresDataStore) SaveTransactions(ctx context.Context, accountID string, transactions []domain.Transaction) error {
+	return p.transactionRepo.SaveBatch(ctx, accountID, transactions)
+}
+
+// GetAccountBalance returns the account balance for a given account ID
+func (p *postgresDataStore) GetAccountBalance(ctx context.Context, accountID string) (decimal.Decimal, error) {
+	return p.transactionRepo.GetBalance(ctx, accountID)
+}
+
+// GetAccountBalanceBefore returns the account balance from the transactions dated before date
+func (p *postgresDataStore) GetAccountBalanceBefore(ctx context.Context, accountID string, date time.Time) (decimal.Decimal, error) {
+	return p.transactionRepo.GetBalanceBefore(ctx, accountID, date)
+}
+
+// GetTransactionsByDateRange returns the account transactions dated within [startDate, endDate]
+func (p *postgresDataStore) GetTransactionsByDateRange(ctx context.Context, accountID string, startDate, endDate time.Time) ([]domain.Transaction, error) {
+	return p.transactionRepo.GetByDateRange(ctx, accountID, startDate, endDate)
+}
+
+// StreamTransactions passes the account transactions dated within [startDate, endDate] to fn
+// in date order, or every account transaction when both dates are zero
+func (p *postgresDataStore) StreamTransactions(ctx context.Context, accountID string, startDate, endDate time.Time, fn func(domain.Transaction) error) error {
+	if startDate.IsZero() && endDate.IsZero() {
+		return p.transactionRepo.EachByAccount(ctx, accountID, fn)
 	}
+	return p.transactionRepo.EachByDateRange(ctx, accountID, startDate, endDate, fn)
+}
 
-	// default
-	defaultEmail := "default@stori.com"
+// GetTransactionsByKeys returns the account transactions with the given IDs and references in date order
+func (p *postgresDataStore) GetTransactionsByKeys(ctx context.Context, accountID string, ids []int, references []string) ([]domain.Transaction, error) {
+	return p.transactionRepo.GetByKeys(ctx, accountID, ids, references)
+}
 
-	account, err := p.accountRepo.GetByEmail(ctx, defaultEmail)
+// SaveAccount returns the ID of the account with the given email, creating it if needed
+func (p *postgresDataStore) SaveAccount(ctx context.Context, email string) (string, error) {
+	return p.accountRepo.Create(ctx, email)
+}
+
+// GetAccountByEmail returns the account with the given email, domain.ErrAccountNotFound
+// when there is none
+func (p *postgresDataStore) GetAccountByEmail(ctx context.Context, email string) (domain.Account, error) {
+	row, err := p.accountRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return fmt.Errorf("checking account existence: %w", err)
+		return domain.Account{}, err
+	}
+	if row == nil {
+		return domain.Account{}, fmt.Errorf("%w: %s", domain.ErrAccountNotFound, email)
 	}
+	return domain.Account{ID: row.ID, Email: row.Email}, nil
+}
 
-	var accountID string
-	if account == nil {
-		accountID, err = p.accountRepo.Create(ctx, defaultEmail)
-		if err != nil {
-			return fmt.Errorf("creating default account: %w", err)
-		}
-	} else {
-		accountID = account.ID
+// ListAccounts returns every account
+func (p *postgresDataStore) ListAccounts(ctx context.Context) ([]domain.Account, error) {
+	rows, err := p.accountRepo.List(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return p.transactionRepo.SaveBatch(ctx, accountID, transactions)
+	accounts := make([]domain.Account, len(rows))
+	for i, row := range rows {
+		accounts[i] = domain.Account{ID: row.ID, Email: row.Email}
+	}
+	return accounts, nil
 }
 
-// GetAccountBalance returns the account balance for a given account ID
-func (p *postgresDataStore) GetAccountBalance(ctx context.Context, accountID string) (decimal.Decimal, error) {
-	return p.transactionRepo.GetBalance(ctx, accountID)
+// ClaimStatement reserves the statement of a period for an account, false if already sent
+// or claimed less than staleAfter ago
+func (p *postgresDataStore) ClaimStatement(ctx context.Context, accountID string, period domain.YearMonth, staleAfter time.Duration) (bool, error) {
+	return p.statementRepo.Claim(ctx, accountID, period.String(), staleAfter)
 }
 
-// SaveAccount creates a new account and returns the account ID
-func (p *postgresDataStore) SaveAccount(ctx context.Context, email string) (string, error) {
-	return p.accountRepo.Create(ctx, email)
+// CompleteStatement marks a claimed statement as sent
+func (p *postgresDataStore) CompleteStatement(ctx context.Context, accountID string, period domain.YearMonth) error {
+	return p.statementRepo.Complete(ctx, accountID, period.String())
+}
+
+// ReleaseStatement drops the claim of a statement that failed to send
+func (p *postgresDataStore) ReleaseStatement(ctx context.Context, accountID string, period domain.YearMonth) error {
+	return p.statementRepo.Release(ctx, accountID, period.String())
+}
+
+// SaveProcessingRun records the processing of a file unit and returns the run ID
+func (p *postgresDataStore) SaveProcessingRun(ctx context.Context, run domain.ProcessingRun) (string, error) {
+	return p.runRepo.Create(ctx, run)
+}
+
+// GetProcessingRun returns a processing run, domain.ErrRunNotFound when there is none
+func (p *postgresDataStore) GetProcessingRun(ctx context.Context, runID string) (domain.ProcessingRun, error) {
+	return p.runRepo.Get(ctx, runID)
+}
+
+// GetQuarantinedRun returns the latest quarantine of the file at quarantinePath,
+// domain.ErrRunNotFound when there is none
+func (p *postgresDataStore) GetQuarantinedRun(ctx context.Context, quarantinePath string) (domain.ProcessingRun, error) {
+	return p.runRepo.GetQuarantined(ctx, quarantinePath)
+}
+
+// ListProcessingRuns returns the latest processing runs, newest first
+func (p *postgresDataStore) ListProcessingRuns(ctx context.Context, limit int) ([]domain.ProcessingRun, error) {
+	return p.runRepo.List(ctx, limit)
+}
+
+// SaveAuditEntry appends an administrative action to the audit trail
+func (p *postgresDataStore) SaveAuditEntry(ctx context.Context, entry domain.AuditEntry) error {
+	return p.runRepo.AddAudit(ctx, entry)
+}
+
+// ListAuditEntries returns the latest audit trail entries, newest first
+func (p *postgresDataStore) ListAuditEntries(ctx context.Context, limit int) ([]domain.AuditEntry, error) {
+	return p.runRepo.ListAudit(ctx, limit)
 }
 
 // Close closes the database connection
@@ -51,7 +51,7 @@ func (r *AccountRepository) Create(ctx context.Context, email string) (string, e
 	return returnedID, nil
 }
 
-// GetByEmail retrieves an account by email
+// GetByEmail retrieves an account by email, compared without case, preferring an exact match
 func (r *AccountRepository) GetByEmail(ctx context.Context, email string) (*Account, error) {
 	r.logger.Debug("retrieving account by email", "email", email)
 
@@ -92,3 +92,33 @@ func (r *AccountRepository) GetByID(ctx context.Context, accountID string) (*Acc
 
 	return &account, nil
 }
+
+// List retrieves every account ordered by email
+func (r *AccountRepository) List(ctx context.Context) ([]Account, error) {
+	r.logger.Debug("listing accounts")
+
+	query, err := r.loader.GetQuery("ListAccounts")
+	if err != nil {
+		return nil, fmt.Errorf("getting list accounts query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("querying accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []Account
+	for rows.Next() {
+		var account Account
+		if err := rows.Scan(&account.ID, &account.Email, &account.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning account row: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning account rows: %w", err)
+	}
+
+	return accounts, nil
+}
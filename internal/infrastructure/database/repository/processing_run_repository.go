@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ProcessingRunRepository records the processing of input files
+type ProcessingRunRepository struct {
+	db     *sql.DB
+	loader *QueryLoader
+	logger *slog.Logger
+}
+
+// NewProcessingRunRepository creates a new processing run repository
+func NewProcessingRunRepository(db *sql.DB, loader *QueryLoader, logger *slog.Logger) *ProcessingRunRepository {
+	return &ProcessingRunRepository{
+		db:     db,
+		loader: loader,
+		logger: logger,
+	}
+}
+
+// Create records a processing run and returns its ID
+func (r *ProcessingRunRepository) Create(ctx context.Context, run domain.ProcessingRun) (string, error) {
+	query, err := r.loader.GetQuery("InsertProcessingRun")
+	if err != nil {
+		return "", fmt.Errorf("getting insert processing run query: %w", err)
+	}
+
+	ids := make([]int64, len(run.TransactionIDs))
+	for i, id := range run.TransactionIDs {
+		ids[i] = int64(id)
+	}
+
+	var runID string
+	err = r.db.QueryRowContext(ctx, query,
+		uuid.New().String(),
+		run.Source,
+		run.Recipient,
+		run.AccountID,
+		run.Status,
+		run.Error,
+		pq.Array(ids),
+		run.QuarantinePath,
+		pq.Array(run.FailedMembers),
+		pq.Array(run.TransactionReferences),
+	).Scan(&runID)
+	if err != nil {
+		return "", fmt.Errorf("inserting processing run: %w", err)
+	}
+
+	r.logger.Debug("processing run recorded", "run_id", runID, "source", run.Source, "status", run.Status)
+	return runID, nil
+}
+
+// Get returns the processing run with the given ID, domain.ErrRunNotFound when there is none
+func (r *ProcessingRunRepository) Get(ctx context.Context, runID string) (domain.ProcessingRun, error) {
+	if _, err := uuid.Parse(runID); err != nil {
+		return domain.ProcessingRun{}, fmt.Errorf("%w: %s", domain.ErrRunNotFound, runID)
+	}
+
+	query, err := r.loader.GetQuery("GetProcessingRun")
+	if err != nil {
+		return domain.ProcessingRun{}, fmt.Errorf("getting processing run query: %w", err)
+	}
+
+	run, err := scanProcessingRun(r.db.QueryRowContext(ctx, query, runID))
+	if err == sql.ErrNoRows {
+		return domain.ProcessingRun{}, fmt.Errorf("%w: %s", domain.ErrRunNotFound, runID)
+	}
+	if err != nil {
+		return domain.ProcessingRun{}, fmt.Errorf("querying processing run: %w", err)
+	}
+	return run, nil
+}
+
+// GetQuarantined returns the latest quarantined run of a file moved to quarantinePath,
+// domain.ErrRunNotFound when there is none
+func (r *ProcessingRunRepository) GetQuarantined(ctx context.Context, quarantinePath string) (domain.ProcessingRun, error) {
+	query, err := r.loader.GetQuery("GetQuarantinedRun")
+	if err != nil {
+		return domain.ProcessingRun{}, fmt.Errorf("getting quarantined run query: %w", err)
+	}
+
+	run, err := scanProcessingRun(r.db.QueryRowContext(ctx, query, quarantinePath))
+	if err == sql.ErrNoRows {
+		return domain.ProcessingRun{}, fmt.Errorf("%w: no quarantine of %s", domain.ErrRunNotFound, quarantinePath)
+	}
+	if err != nil {
+		return domain.ProcessingRun{}, fmt.Errorf("querying quarantined run: %w", err)
+	}
+	return run, nil
+}
+
+// List returns the latest processing runs, newest first
+func (r *ProcessingRunRepository) List(ctx context.Context, limit int) ([]domain.ProcessingRun, error) {
+	query, err := r.loader.GetQuery("ListProcessingRuns")
+	if err != nil {
+		return nil, fmt.Errorf("getting list processing runs query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying processing runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.ProcessingRun
+	for rows.Next() {
+		run, err := scanProcessingRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning processing run row: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning processing run rows: %w", err)
+	}
+	return runs, nil
+}
+
+// scanner is a single row of a query result
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProcessingRun(row scanner) (domain.ProcessingRun, error) {
+	var (
+		run        domain.ProcessingRun
+		ids        pq.Int64Array
+		references pq.StringArray
+		members    pq.StringArray
+	)
+	err := row.Scan(&run.ID, &run.Source, &run.Recipient, &run.AccountID, &run.Status, &run.Error, &ids, &references, &run.QuarantinePath, &members, &run.CreatedAt)
+	if err != nil {
+		return domain.ProcessingRun{}, err
+	}
+
+	run.TransactionIDs = make([]int, len(ids))
+	for i, id := range ids {
+		run.TransactionIDs[i] = int(id)
+	}
+	// Runs recorded before references were kept have none
+	if len(references) > 0 {
+		run.TransactionReferences = references
+	}
+	run.FailedMembers = members
+	return run, nil
+}
+
+// AddAudit appends an entry to the audit trail
+func (r *ProcessingRunRepository) AddAudit(ctx context.Context, entry domain.AuditEntry) error {
+	query, err := r.loader.GetQuery("InsertAuditEntry")
+	if err != nil {
+		return fmt.Errorf("getting insert audit entry query: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, entry.Action, entry.Actor, entry.Target, entry.Error); err != nil {
+		return fmt.Errorf("inserting audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAudit returns the latest entries of the audit trail, newest first
+func (r *ProcessingRunRepository) ListAudit(ctx context.Context, limit int) ([]domain.AuditEntry, error) {
+	query, err := r.loader.GetQuery("ListAuditEntries")
+	if err != nil {
+		return nil, fmt.Errorf("getting list audit entries query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		if err := rows.Scan(&entry.Action, &entry.Actor, &entry.Target, &entry.Error, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning audit entry row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning audit entry rows: %w", err)
+	}
+	return entries, nil
+}
@@ -7,9 +7,16 @@ RETURNING id;
 -- name: GetAccountByEmail :one
 SELECT id, email, created_at
 FROM accounts
-WHERE email = $1;
+WHERE LOWER(email) = LOWER($1)
+ORDER BY email = $1 DESC, created_at
+LIMIT 1;
 
 -- name: GetAccountByID :one
 SELECT id, email, created_at
 FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListAccounts :many
+SELECT id, email, created_at
+FROM accounts
+ORDER BY email;
@@ -0,0 +1,33 @@
+-- name: InsertProcessingRun :one
+INSERT INTO processing_runs (id, source, recipient, account_id, status, error, transaction_ids, quarantine_path, failed_members, transaction_references, created_at)
+VALUES ($1, $2, $3, NULLIF($4, '')::UUID, $5, $6, $7, $8, COALESCE($9::TEXT[], '{}'), COALESCE($10::TEXT[], '{}'), NOW())
+RETURNING id;
+
+-- name: GetProcessingRun :one
+SELECT id, source, recipient, COALESCE(account_id::TEXT, ''), status, error, transaction_ids, transaction_references, quarantine_path, failed_members, created_at
+FROM processing_runs
+WHERE id = $1;
+
+-- name: GetQuarantinedRun :one
+SELECT id, source, recipient, COALESCE(account_id::TEXT, ''), status, error, transaction_ids, transaction_references, quarantine_path, failed_members, created_at
+FROM processing_runs
+WHERE quarantine_path = $1
+  AND status = 'quarantined'
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ListProcessingRuns :many
+SELECT id, source, recipient, COALESCE(account_id::TEXT, ''), status, error, transaction_ids, transaction_references, quarantine_path, failed_members, created_at
+FROM processing_runs
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: InsertAuditEntry :exec
+INSERT INTO audit_log (action, actor, target, error, created_at)
+VALUES ($1, $2, $3, $4, NOW());
+
+-- name: ListAuditEntries :many
+SELECT action, actor, target, error, created_at
+FROM audit_log
+ORDER BY created_at DESC, id DESC
+LIMIT $1;
@@ -0,0 +1,17 @@
+-- name: ClaimStatementRun :one
+INSERT INTO statement_runs (account_id, period, status, claimed_at)
+VALUES ($1, $2, 'sending', NOW())
+ON CONFLICT (account_id, period) DO UPDATE
+SET claimed_at = NOW()
+WHERE statement_runs.status = 'sending'
+  AND statement_runs.claimed_at < NOW() - make_interval(secs => $3)
+RETURNING account_id;
+
+-- name: CompleteStatementRun :exec
+UPDATE statement_runs
+SET status = 'sent', sent_at = NOW()
+WHERE account_id = $1 AND period = $2;
+
+-- name: ReleaseStatementRun :exec
+DELETE FROM statement_runs
+WHERE account_id = $1 AND period = $2 AND status = 'sending';
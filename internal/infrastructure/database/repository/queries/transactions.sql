@@ -1,23 +1,42 @@
 -- name: InsertTransaction :exec
-INSERT INTO transactions (id, account_id, transaction_date, amount, transaction_type, processed_at)
-VALUES ($1, $2, $3, $4, $5, NOW())
-ON CONFLICT (id) DO NOTHING;
+INSERT INTO transactions (id, account_id, transaction_date, amount, transaction_type, reference, description, currency, processed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+ON CONFLICT (account_id, id) WHERE reference = '' DO NOTHING;
+
+-- name: InsertReferencedTransaction :exec
+INSERT INTO transactions (id, account_id, transaction_date, amount, transaction_type, reference, description, currency, processed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+ON CONFLICT (account_id, reference) WHERE reference <> '' DO NOTHING;
 
 -- name: GetAccountBalance :one
 SELECT COALESCE(SUM(amount), 0) as balance
 FROM transactions
 WHERE account_id = $1;
 
+-- name: GetAccountBalanceBefore :one
+SELECT COALESCE(SUM(amount), 0) as balance
+FROM transactions
+WHERE account_id = $1
+  AND transaction_date < $2;
+
 -- name: GetTransactionsByAccount :many
-SELECT id, account_id, transaction_date, amount, transaction_type, processed_at
+SELECT id, account_id, transaction_date, amount, transaction_type, reference, description, currency, processed_at
 FROM transactions
 WHERE account_id = $1
-ORDER BY transaction_date DESC, processed_at DESC;
+ORDER BY transaction_date, id;
 
 -- name: GetTransactionsByDateRange :many
-SELECT id, account_id, transaction_date, amount, transaction_type, processed_at
+SELECT id, account_id, transaction_date, amount, transaction_type, reference, description, currency, processed_at
 FROM transactions
 WHERE account_id = $1 
   AND transaction_date >= $2 
   AND transaction_date <= $3
-ORDER BY transaction_date DESC, processed_at DESC;
\ No newline at end of file
+ORDER BY transaction_date, id;
+
+-- name: GetTransactionsByKeys :many
+SELECT t.id, t.account_id, t.transaction_date, t.amount, t.transaction_type, t.reference, t.description, t.currency, t.processed_at
+FROM transactions t
+JOIN UNNEST($2::INTEGER[]) WITH ORDINALITY AS k(id, n) ON t.id = k.id
+WHERE t.account_id = $1
+  AND (COALESCE(CARDINALITY($3::TEXT[]), 0) = 0 OR t.reference = ($3::TEXT[])[k.n])
+ORDER BY t.transaction_date, t.id;
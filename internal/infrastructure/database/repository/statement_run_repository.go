@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// StatementRunRepository tracks which statement periods were sent to each account
+type StatementRunRepository struct {
+	db     *sql.DB
+	loader *QueryLoader
+	logger *slog.Logger
+}
+
+// NewStatementRunRepository creates a new statement run repository
+func NewStatementRunRepository(db *sql.DB, loader *QueryLoader, logger *slog.Logger) *StatementRunRepository {
+	return &StatementRunRepository{
+		db:     db,
+		loader: loader,
+		logger: logger,
+	}
+}
+
+// Claim records that the statement for period is being sent to the account. It returns
+// false when the period was already sent or claimed, so concurrent schedulers never send
+// it twice. A claim older than staleAfter is taken over: its sender stopped before
+// completing or releasing it
+func (r *StatementRunRepository) Claim(ctx context.Context, accountID, period string, staleAfter time.Duration) (bool, error) {
+	query, err := r.loader.GetQuery("ClaimStatementRun")
+	if err != nil {
+		return false, fmt.Errorf("getting claim statement run query: %w", err)
+	}
+
+	var claimedID string
+	err = r.db.QueryRowContext(ctx, query, accountID, period, staleAfter.Seconds()).Scan(&claimedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("claiming statement run: %w", err)
+	}
+
+	r.logger.Debug("statement run claimed", "account_id", accountID, "period", period)
+	return true, nil
+}
+
+// Complete marks a claimed statement as sent
+func (r *StatementRunRepository) Complete(ctx context.Context, accountID, period string) error {
+	return r.exec(ctx, "CompleteStatementRun", accountID, period)
+}
+
+// Release drops a claim whose statement could not be sent, so a later run retries it
+func (r *StatementRunRepository) Release(ctx context.Context, accountID, period string) error {
+	return r.exec(ctx, "ReleaseStatementRun", accountID, period)
+}
+
+func (r *StatementRunRepository) exec(ctx context.Context, name, accountID, period string) error {
+	query, err := r.loader.GetQuery(name)
+	if err != nil {
+		return fmt.Errorf("getting %s query: %w", name, err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, accountID, period); err != nil {
+		return fmt.Errorf("executing %s: %w", name, err)
+	}
+	return nil
+}
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 )
 
@@ -38,6 +40,10 @@ func (r *TransactionRepository) SaveBatch(ctx context.Context, accountID string,
 	if err != nil {
 		return fmt.Errorf("getting insert transaction query: %w", err)
 	}
+	referencedQuery, err := r.loader.GetQuery("InsertReferencedTransaction")
+	if err != nil {
+		return fmt.Errorf("getting insert referenced transaction query: %w", err)
+	}
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -51,29 +57,52 @@ func (r *TransactionRepository) SaveBatch(ctx context.Context, accountID string,
 	}
 	defer stmt.Close()
 
+	// Bank transactions are deduplicated by their reference, as their ID is derived from it
+	referencedStmt, err := tx.PrepareContext(ctx, referencedQuery)
+	if err != nil {
+		return fmt.Errorf("preparing insert referenced statement: %w", err)
+	}
+	defer referencedStmt.Close()
+
+	var skipped int
 	for _, transaction := range transactions {
 		amount := transaction.Amount
 		if transaction.Type == domain.Debit {
 			amount = amount.Neg()
 		}
 
-		_, err := stmt.ExecContext(ctx,
+		insert := stmt
+		if transaction.Reference != "" {
+			insert = referencedStmt
+		}
+		result, err := insert.ExecContext(ctx,
 			transaction.ID,
 			accountID,
 			transaction.Date,
 			amount,
 			transaction.Type.String(),
+			transaction.Reference,
+			transaction.Description,
+			transaction.Currency,
 		)
 		if err != nil {
 			return fmt.Errorf("inserting transaction %d: %w", transaction.ID, err)
 		}
+		// A transaction the account already holds is kept as it was saved
+		if inserted, err := result.RowsAffected(); err == nil && inserted == 0 {
+			skipped++
+			r.logger.Debug("transaction already saved", "id", transaction.ID, "reference", transaction.Reference, "account_id", accountID)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("committing transaction: %w", err)
 	}
 
-	r.logger.Info("transaction batch saved", "count", len(transactions), "account_id", accountID)
+	if skipped > 0 {
+		r.logger.Warn("skipped transactions already saved for the account", "count", skipped, "account_id", accountID)
+	}
+	r.logger.Info("transaction batch saved", "count", len(transactions)-skipped, "account_id", accountID)
 	return nil
 }
 
@@ -98,26 +127,64 @@ func (r *TransactionRepository) GetBalance(ctx context.Context, accountID string
 	return balance, nil
 }
 
+// GetBalanceBefore retrieves the balance of an account before a date
+func (r *TransactionRepository) GetBalanceBefore(ctx context.Context, accountID string, date time.Time) (decimal.Decimal, error) {
+	r.logger.Debug("retrieving account balance before date", "account_id", accountID, "date", date.Format("2006-01-02"))
+
+	query, err := r.loader.GetQuery("GetAccountBalanceBefore")
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("getting balance before date query: %w", err)
+	}
+
+	var balance decimal.Decimal
+	if err := r.db.QueryRowContext(ctx, query, accountID, date).Scan(&balance); err != nil {
+		return decimal.Zero, fmt.Errorf("querying account balance before %s: %w", date.Format("2006-01-02"), err)
+	}
+	return balance, nil
+}
+
 // GetByAccount retrieves all transactions for an account
 func (r *TransactionRepository) GetByAccount(ctx context.Context, accountID string) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	err := r.EachByAccount(ctx, accountID, func(transaction domain.Transaction) error {
+		transactions = append(transactions, transaction)
+		return nil
+	})
+	return transactions, err
+}
+
+// EachByAccount streams all transactions of an account to fn in date order, stopping at
+// the first error of fn
+func (r *TransactionRepository) EachByAccount(ctx context.Context, accountID string, fn func(domain.Transaction) error) error {
 	r.logger.Debug("retrieving transactions by account", "account_id", accountID)
 
 	query, err := r.loader.GetQuery("GetTransactionsByAccount")
 	if err != nil {
-		return nil, fmt.Errorf("getting transactions by account query: %w", err)
+		return fmt.Errorf("getting transactions by account query: %w", err)
 	}
 
 	rows, err := r.db.QueryContext(ctx, query, accountID)
 	if err != nil {
-		return nil, fmt.Errorf("querying transactions by account: %w", err)
+		return fmt.Errorf("querying transactions by account: %w", err)
 	}
 	defer rows.Close()
 
-	return r.scanTransactions(rows)
+	return r.scanTransactions(rows, fn)
 }
 
 // GetByDateRange retrieves transactions for an account within a date range
 func (r *TransactionRepository) GetByDateRange(ctx context.Context, accountID string, startDate, endDate time.Time) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	err := r.EachByDateRange(ctx, accountID, startDate, endDate, func(transaction domain.Transaction) error {
+		transactions = append(transactions, transaction)
+		return nil
+	})
+	return transactions, err
+}
+
+// EachByDateRange streams the transactions of an account within a date range to fn in
+// date order, stopping at the first error of fn
+func (r *TransactionRepository) EachByDateRange(ctx context.Context, accountID string, startDate, endDate time.Time, fn func(domain.Transaction) error) error {
 	r.logger.Debug("retrieving transactions by date range",
 		"account_id", accountID,
 		"start_date", startDate.Format("2006-01-02"),
@@ -125,21 +192,49 @@ func (r *TransactionRepository) GetByDateRange(ctx context.Context, accountID st
 
 	query, err := r.loader.GetQuery("GetTransactionsByDateRange")
 	if err != nil {
-		return nil, fmt.Errorf("getting transactions by date range query: %w", err)
+		return fmt.Errorf("getting transactions by date range query: %w", err)
 	}
 
 	rows, err := r.db.QueryContext(ctx, query, accountID, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("querying transactions by date range: %w", err)
+		return fmt.Errorf("querying transactions by date range: %w", err)
 	}
 	defer rows.Close()
 
-	return r.scanTransactions(rows)
+	return r.scanTransactions(rows, fn)
 }
 
-func (r *TransactionRepository) scanTransactions(rows *sql.Rows) ([]domain.Transaction, error) {
+// GetByKeys retrieves the transactions of an account identified by each ID and the reference
+// at the same index in date order. Without references, transactions are matched by ID
+func (r *TransactionRepository) GetByKeys(ctx context.Context, accountID string, ids []int, references []string) ([]domain.Transaction, error) {
+	query, err := r.loader.GetQuery("GetTransactionsByKeys")
+	if err != nil {
+		return nil, fmt.Errorf("getting transactions by keys query: %w", err)
+	}
+	if references != nil && len(references) != len(ids) {
+		return nil, fmt.Errorf("%d transaction references for %d IDs", len(references), len(ids))
+	}
+
+	values := make([]int64, len(ids))
+	for i, id := range ids {
+		values[i] = int64(id)
+	}
+	rows, err := r.db.QueryContext(ctx, query, accountID, pq.Array(values), pq.Array(references))
+	if err != nil {
+		return nil, fmt.Errorf("querying transactions by keys: %w", err)
+	}
+	defer rows.Close()
+
 	var transactions []domain.Transaction
+	err = r.scanTransactions(rows, func(transaction domain.Transaction) error {
+		transactions = append(transactions, transaction)
+		return nil
+	})
+	return transactions, err
+}
 
+// scanTransactions passes each row to fn as it is read, without holding the result set in memory
+func (r *TransactionRepository) scanTransactions(rows *sql.Rows, fn func(domain.Transaction) error) error {
 	for rows.Next() {
 		var (
 			id              int
@@ -147,12 +242,15 @@ func (r *TransactionRepository) scanTransactions(rows *sql.Rows) ([]domain.Trans
 			transactionDate time.Time
 			amount          decimal.Decimal
 			transactionType string
+			reference       string
+			description     string
+			currency        string
 			processedAt     sql.NullTime
 		)
 
-		err := rows.Scan(&id, &accountID, &transactionDate, &amount, &transactionType, &processedAt)
+		err := rows.Scan(&id, &accountID, &transactionDate, &amount, &transactionType, &reference, &description, &currency, &processedAt)
 		if err != nil {
-			return nil, fmt.Errorf("scanning transaction row: %w", err)
+			return fmt.Errorf("scanning transaction row: %w", err)
 		}
 
 		var txType domain.TransactionType
@@ -168,18 +266,23 @@ func (r *TransactionRepository) scanTransactions(rows *sql.Rows) ([]domain.Trans
 		}
 
 		transaction := domain.Transaction{
-			ID:     id,
-			Date:   transactionDate,
-			Amount: amount,
-			Type:   txType,
+			ID:          id,
+			Date:        transactionDate,
+			Amount:      amount,
+			Type:        txType,
+			Reference:   reference,
+			Description: description,
+			Currency:    strings.TrimSpace(currency),
 		}
 
-		transactions = append(transactions, transaction)
+		if err := fn(transaction); err != nil {
+			return err
+		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("scanning transaction rows: %w", err)
+		return fmt.Errorf("scanning transaction rows: %w", err)
 	}
 
-	return transactions, nil
+	return nil
 }
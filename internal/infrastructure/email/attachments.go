@@ -0,0 +1,115 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/file"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/statement"
+)
+
+const (
+	AttachmentCSV  = "csv"
+	AttachmentPDF  = "pdf"
+	AttachmentNone = "none"
+)
+
+// chartContentID identifies the inline monthly activity chart within a message
+const chartContentID = "activity-chart@stori"
+
+type attachment struct {
+	filename    string
+	contentType string
+	// contentID is set on inline parts referenced from the HTML body as cid:<contentID>
+	contentID string
+	data      []byte
+}
+
+// ParseAttachmentKinds parses a comma separated attachment list (e.g., "csv,pdf").
+// "none" yields an empty, non-nil selection so it can override a default
+func ParseAttachmentKinds(value string) ([]string, error) {
+	kinds := []string{}
+	for _, kind := range strings.Split(value, ",") {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		switch kind {
+		case "", AttachmentNone:
+			continue
+		case AttachmentCSV, AttachmentPDF:
+			kinds = append(kinds, kind)
+		default:
+			return nil, fmt.Errorf("unknown attachment type %q", kind)
+		}
+	}
+	return kinds, nil
+}
+
+// ParseAccountAttachments parses per-account attachment overrides in the form
+// "alice@example.com=csv,pdf;bob@example.com=none"
+func ParseAccountAttachments(value string) (map[string][]string, error) {
+	overrides := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		account, kinds, found := strings.Cut(entry, "=")
+		if !found || strings.TrimSpace(account) == "" {
+			return nil, fmt.Errorf("invalid account attachment entry %q", entry)
+		}
+
+		parsed, err := ParseAttachmentKinds(kinds)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", account, err)
+		}
+		overrides[strings.ToLower(strings.TrimSpace(account))] = parsed
+	}
+	return overrides, nil
+}
+
+// attachmentsFor returns the attachment kinds selected for a recipient
+func (c SMTPConfig) attachmentsFor(recipient string) []string {
+	if kinds, ok := c.AccountAttachments[strings.ToLower(recipient)]; ok {
+		return kinds
+	}
+	return c.Attachments
+}
+
+func buildAttachments(kinds []string, recipient string, locale i18n.Locale, summary *domain.Summary, transactions []domain.Transaction) ([]attachment, error) {
+	var attachments []attachment
+	stamp := time.Now().Format("2006-01-02")
+
+	for _, kind := range kinds {
+		switch kind {
+		case AttachmentCSV:
+			var buf bytes.Buffer
+			if err := file.WriteTransactionsCSV(&buf, transactions); err != nil {
+				return nil, fmt.Errorf("building CSV attachment: %w", err)
+			}
+			attachments = append(attachments, attachment{
+				filename:    "transactions-" + stamp + ".csv",
+				contentType: "text/csv; charset=utf-8",
+				data:        buf.Bytes(),
+			})
+		case AttachmentPDF:
+			data, err := statement.RenderPDF(summary, transactions, statement.Options{
+				Locale:  locale,
+				Account: recipient,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("building PDF attachment: %w", err)
+			}
+			attachments = append(attachments, attachment{
+				filename:    "statement-" + stamp + ".pdf",
+				contentType: "application/pdf",
+				data:        data,
+			})
+		}
+	}
+
+	return attachments, nil
+}
@@ -0,0 +1,126 @@
+package email
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+func TestParseAttachmentKinds(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "csv,pdf", want: []string{AttachmentCSV, AttachmentPDF}},
+		{value: " PDF , csv ", want: []string{AttachmentPDF, AttachmentCSV}},
+		{value: "", want: []string{}},
+		{value: "none", want: []string{}},
+		{value: "csv,zip", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAttachmentKinds(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAttachmentKinds(%q) succeeded, want an error", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAttachmentKinds(%q): %v", tt.value, err)
+			continue
+		}
+		// An empty selection must not be nil, so it can override the default
+		if got == nil || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseAttachmentKinds(%q) = %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAttachmentsFor(t *testing.T) {
+	overrides, err := ParseAccountAttachments("Alice@Example.com=pdf;bob@example.com=none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := SMTPConfig{Attachments: []string{AttachmentCSV}, AccountAttachments: overrides}
+
+	tests := map[string][]string{
+		"alice@example.com": {AttachmentPDF},
+		"ALICE@example.COM": {AttachmentPDF},
+		"Bob@Example.com":   {},
+		"carol@example.com": {AttachmentCSV},
+	}
+	for recipient, want := range tests {
+		if got := config.attachmentsFor(recipient); !slices.Equal(got, want) {
+			t.Errorf("attachmentsFor(%s) = %v, want %v", recipient, got, want)
+		}
+	}
+}
+
+func TestSendSummaryAttachmentParts(t *testing.T) {
+	mailbox := NewMailbox()
+	config := SMTPConfig{From: "noreply@stori.com", Attachments: []string{AttachmentCSV}}
+	service := NewMailboxEmailService(config, mailbox, nil, discardLogger())
+
+	transactions := testTransactions()
+	if err := service.SendSummary(context.Background(), "user@example.com", domain.NewSummary(transactions), transactions); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(mailbox.Messages()[0].Data)))
+	if err != nil {
+		t.Fatalf("parsing message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %s, %v; want multipart/mixed", mediaType, err)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	body, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("reading body part: %v", err)
+	}
+	if bodyType, _, _ := mime.ParseMediaType(body.Header.Get("Content-Type")); bodyType != "multipart/related" {
+		t.Errorf("first part Content-Type = %s, want the multipart/related HTML body", bodyType)
+	}
+
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("reading attachment part: %v", err)
+	}
+	if got := part.Header.Get("Content-Transfer-Encoding"); got != "base64" {
+		t.Errorf("Content-Transfer-Encoding = %s, want base64", got)
+	}
+	disposition, dispositionParams, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+	if err != nil || disposition != "attachment" || !strings.HasSuffix(dispositionParams["filename"], ".csv") {
+		t.Errorf("Content-Disposition = %s %v, want an attachment named *.csv", disposition, dispositionParams)
+	}
+
+	encoded, _ := io.ReadAll(part)
+	for _, line := range strings.Split(strings.TrimRight(string(encoded), "\r\n"), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 line of %d characters, want at most 76", len(line))
+		}
+	}
+	csv, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil {
+		t.Fatalf("decoding attachment: %v", err)
+	}
+	if want := "Id,Date,Transaction\n1,2024-07-15,+60.50\n"; !strings.HasPrefix(string(csv), want) {
+		t.Errorf("attachment = %q, want it to start with %q", csv, want)
+	}
+
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("unexpected part after the attachment: %v", err)
+	}
+}
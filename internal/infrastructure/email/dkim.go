@@ -0,0 +1,213 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dkimSignedHeaders are signed when present in the message, in this order
+var dkimSignedHeaders = []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"}
+
+type DKIMConfig struct {
+	Domain         string
+	Selector       string
+	PrivateKeyFile string
+	// Key is loaded from PrivateKeyFile; signing is enabled when it is set
+	Key crypto.Signer
+}
+
+// Enabled returns true if outgoing messages must be DKIM signed
+func (c DKIMConfig) Enabled() bool {
+	return c.Key != nil
+}
+
+// LoadDKIMKey reads an RSA (PKCS#1 or PKCS#8) or Ed25519 (PKCS#8) private key from a PEM file
+func LoadDKIMKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading DKIM key %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing DKIM key: %w", err)
+		}
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case ed25519.PrivateKey:
+			return k, nil
+		default:
+			return nil, fmt.Errorf("unsupported DKIM key type %T", key)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+}
+
+// signDKIM prepends a DKIM-Signature header using relaxed/relaxed canonicalization (RFC 6376).
+// Line endings are normalized to CRLF first, as they will be on the wire
+func signDKIM(config DKIMConfig, message []byte) ([]byte, error) {
+	message = normalizeCRLF(message)
+
+	headerEnd := bytes.Index(message, []byte("\r\n\r\n"))
+	if headerEnd < 0 {
+		return nil, errors.New("message has no header/body separator")
+	}
+	headerBlock := string(message[:headerEnd+2])
+	body := message[headerEnd+4:]
+
+	var algorithm string
+	var hashed bool
+	switch config.Key.(type) {
+	case *rsa.PrivateKey:
+		algorithm, hashed = "rsa-sha256", true
+	case ed25519.PrivateKey:
+		algorithm, hashed = "ed25519-sha256", false
+	default:
+		return nil, fmt.Errorf("unsupported DKIM key type %T", config.Key)
+	}
+
+	bodyHash := sha256.Sum256(relaxedBody(body))
+
+	headers := parseHeaders(headerBlock)
+	var signedNames []string
+	var canonical strings.Builder
+	for _, name := range dkimSignedHeaders {
+		if value, ok := headers[strings.ToLower(name)]; ok {
+			signedNames = append(signedNames, strings.ToLower(name))
+			canonical.WriteString(relaxedHeader(name, value))
+			canonical.WriteString("\r\n")
+		}
+	}
+
+	signatureValue := fmt.Sprintf("v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%s; h=%s; bh=%s; b=",
+		algorithm,
+		config.Domain,
+		config.Selector,
+		strconv.FormatInt(time.Now().Unix(), 10),
+		strings.Join(signedNames, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]))
+	canonical.WriteString(relaxedHeader("DKIM-Signature", signatureValue))
+
+	digest := sha256.Sum256([]byte(canonical.String()))
+
+	var signature []byte
+	var err error
+	if hashed {
+		signature, err = config.Key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	} else {
+		// Ed25519 signs the SHA-256 digest directly (RFC 8463)
+		signature, err = config.Key.Sign(rand.Reader, digest[:], crypto.Hash(0))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing message: %w", err)
+	}
+
+	var signed bytes.Buffer
+	signed.WriteString("DKIM-Signature: ")
+	signed.WriteString(signatureValue)
+	signed.WriteString(base64.StdEncoding.EncodeToString(signature))
+	signed.WriteString("\r\n")
+	signed.Write(message)
+
+	return signed.Bytes(), nil
+}
+
+func normalizeCRLF(message []byte) []byte {
+	message = bytes.ReplaceAll(message, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(message, []byte("\n"), []byte("\r\n"))
+}
+
+// parseHeaders returns the last occurrence of every header, keyed by lower-case name,
+// with folded continuation lines preserved
+func parseHeaders(block string) map[string]string {
+	headers := make(map[string]string)
+
+	var name string
+	var value strings.Builder
+	flush := func() {
+		if name != "" {
+			headers[strings.ToLower(name)] = value.String()
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(block, "\r\n"), "\r\n") {
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			value.WriteString("\r\n")
+			value.WriteString(line)
+			continue
+		}
+		flush()
+		key, rest, _ := strings.Cut(line, ":")
+		name = key
+		value.Reset()
+		value.WriteString(rest)
+	}
+	flush()
+
+	return headers
+}
+
+// relaxedHeader canonicalizes a header: lower-case name, unfolded value with
+// whitespace runs collapsed and surrounding whitespace removed
+func relaxedHeader(name, value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(collapseWhitespace(value))
+}
+
+// relaxedBody canonicalizes a CRLF body: whitespace runs collapsed, trailing
+// whitespace and trailing empty lines removed
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(collapseWhitespace(line), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	inSpace := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == ' ' || c == '\t' {
+			if !inSpace {
+				b.WriteByte(' ')
+			}
+			inSpace = true
+			continue
+		}
+		inSpace = false
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
+)
+
+func TestRelaxedCanonicalization(t *testing.T) {
+	// Example from RFC 6376, section 3.4.5
+	if got := relaxedHeader("A", " X"); got != "a:X" {
+		t.Errorf("relaxedHeader(A) = %q, want %q", got, "a:X")
+	}
+	if got := relaxedHeader("B ", " Y\t\r\n\tZ  "); got != "b:Y Z" {
+		t.Errorf("relaxedHeader(B) = %q, want %q", got, "b:Y Z")
+	}
+
+	body := " C \r\nD \t E\r\n\r\n\r\n"
+	if got := string(relaxedBody([]byte(body))); got != " C\r\nD E\r\n" {
+		t.Errorf("relaxedBody = %q, want %q", got, " C\r\nD E\r\n")
+	}
+
+	if got := relaxedBody([]byte("\r\n\r\n")); len(got) != 0 {
+		t.Errorf("relaxedBody of empty lines = %q, want empty", got)
+	}
+}
+
+func TestSignDKIM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	message := "From: noreply@stori.com\nTo: user@example.com\nSubject: Summary\n\n<p>Hello</p>\n"
+	signed, err := signDKIM(DKIMConfig{Domain: "stori.com", Selector: "mail", Key: key}, []byte(message))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	header, rest, _ := strings.Cut(string(signed), "\r\n")
+	value := strings.TrimPrefix(header, "DKIM-Signature: ")
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(tag), "=")
+		tags[k] = v
+	}
+
+	if tags["d"] != "stori.com" || tags["s"] != "mail" || tags["c"] != "relaxed/relaxed" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+	if tags["h"] != "from:to:subject" {
+		t.Errorf("h = %s, want from:to:subject", tags["h"])
+	}
+
+	bodyHash := sha256.Sum256([]byte("<p>Hello</p>\r\n"))
+	if tags["bh"] != base64.StdEncoding.EncodeToString(bodyHash[:]) {
+		t.Errorf("bh = %s, want hash of canonical body", tags["bh"])
+	}
+
+	if !strings.HasPrefix(rest, "From: noreply@stori.com\r\n") {
+		t.Errorf("signed message does not keep the original headers with CRLF line endings")
+	}
+
+	canonical := "from:noreply@stori.com\r\nto:user@example.com\r\nsubject:Summary\r\n" +
+		relaxedHeader("DKIM-Signature", strings.TrimSuffix(value, tags["b"]))
+	digest := sha256.Sum256([]byte(canonical))
+	signature, _ := base64.StdEncoding.DecodeString(tags["b"])
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
+
+func TestSignDKIMLocalizedMessage(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	mailbox := NewMailbox()
+	config := SMTPConfig{
+		From:   "noreply@stori.com",
+		Locale: i18n.Spanish,
+		DKIM:   DKIMConfig{Domain: "stori.com", Selector: "mail", Key: key},
+	}
+	service := NewMailboxEmailService(config, mailbox, nil, discardLogger())
+	transactions := testTransactions()
+	if err := service.SendSummary(context.Background(), "user@example.com", domain.NewSummary(transactions), transactions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	signed := mailbox.Messages()[0].Data
+
+	// A 7-bit message is relayed unchanged, even by servers without 8BITMIME
+	for i, b := range signed {
+		if b >= 0x80 {
+			t.Fatalf("byte %d of the signed message is not 7-bit: %q", i, signed[max(0, i-20):i+1])
+		}
+	}
+
+	_, body, _ := strings.Cut(string(signed), "\r\n\r\n")
+	bodyHash := sha256.Sum256(relaxedBody([]byte(body)))
+	header, _, _ := strings.Cut(string(signed), "\r\n")
+	if want := "bh=" + base64.StdEncoding.EncodeToString(bodyHash[:]) + ";"; !strings.Contains(header, want) {
+		t.Errorf("DKIM-Signature %s does not hold the hash of the body, %s", header, want)
+	}
+
+	// The Spanish text survives the quoted-printable encoding
+	msg, err := mail.ReadMessage(bytes.NewReader(signed))
+	if err != nil {
+		t.Fatalf("parsing message: %v", err)
+	}
+	_, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	part, err := multipart.NewReader(msg.Body, params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatalf("reading HTML part: %v", err)
+	}
+	html, _ := io.ReadAll(part)
+	if !strings.Contains(string(html), "Este es un mensaje automático") {
+		t.Error("decoded HTML does not hold the Spanish footer")
+	}
+}
@@ -0,0 +1,99 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]+`)
+
+type fileTransport struct {
+	directory string
+	logger    *slog.Logger
+}
+
+func (t *fileTransport) send(ctx context.Context, from string, recipients []string, message []byte) error {
+	if err := os.MkdirAll(t.directory, 0o755); err != nil {
+		return fmt.Errorf("creating email directory %s: %w", t.directory, err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml",
+		time.Now().Format("20060102T150405.000000000"),
+		unsafeFileChars.ReplaceAllString(recipients[0], "_"))
+	path := filepath.Join(t.directory, name)
+
+	if err := os.WriteFile(path, message, 0o644); err != nil {
+		return fmt.Errorf("writing email file %s: %w", path, err)
+	}
+
+	t.logger.Info("email written to file", "file", path, "recipients", recipients)
+	return nil
+}
+
+// NewMailboxEmailService creates an email service that delivers every rendered message
+// into mailbox, where the caller can read it. A nil store uses the embedded templates
+func NewMailboxEmailService(config SMTPConfig, mailbox *Mailbox, templates *TemplateStore, logger *slog.Logger) services.EmailService {
+	service := newEmailService(config, mailbox, logger)
+	service.templates = templates
+	return service
+}
+
+// Message is an email captured by a mailbox
+type Message struct {
+	From       string
+	Recipients []string
+	Data       []byte
+}
+
+// Mailbox stores the messages delivered through a mailbox email service
+type Mailbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMailbox creates an empty mailbox
+func NewMailbox() *Mailbox {
+	return &Mailbox{}
+}
+
+// Messages returns a copy of the delivered messages in delivery order
+func (m *Mailbox) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]Message, len(m.messages))
+	copy(messages, m.messages)
+	return messages
+}
+
+// Reset discards all delivered messages
+func (m *Mailbox) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
+
+func (m *Mailbox) send(ctx context.Context, from string, recipients []string, message []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, Message{
+		From:       from,
+		Recipients: append([]string(nil), recipients...),
+		Data:       append([]byte(nil), message...),
+	})
+	return nil
+}
@@ -0,0 +1,89 @@
+package email
+
+import (
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// Fixture is a named sample summary used to validate and preview templates
+type Fixture struct {
+	Name         string
+	Summary      *domain.Summary
+	Transactions []domain.Transaction
+}
+
+// Fixtures returns sample summaries covering the template branches: no transactions,
+// credits only (compared with a previous period), debits only and activity spanning
+// several years (with a persisted account balance)
+func Fixtures() []Fixture {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	tx := func(id int, when time.Time, amount string, txType domain.TransactionType) domain.Transaction {
+		return domain.Transaction{ID: id, Date: when, Amount: decimal.RequireFromString(amount), Type: txType}
+	}
+
+	sets := []struct {
+		name         string
+		transactions []domain.Transaction
+		// history holds transactions of the previous period, for the comparison section
+		history []domain.Transaction
+		// accountBalance is the persisted account balance, empty when unknown
+		accountBalance string
+	}{
+		{name: "empty"},
+		{
+			name: "credits-only",
+			transactions: []domain.Transaction{
+				tx(1, date(2024, 7, 15), "60.5", domain.Credit),
+				tx(2, date(2024, 7, 28), "120", domain.Credit),
+				tx(3, date(2024, 8, 2), "15.25", domain.Credit),
+			},
+			history: []domain.Transaction{
+				tx(101, date(2024, 5, 10), "150", domain.Credit),
+				tx(102, date(2024, 6, 3), "42.8", domain.Debit),
+			},
+		},
+		{
+			name: "debits-only",
+			transactions: []domain.Transaction{
+				tx(1, date(2024, 7, 15), "10.3", domain.Debit),
+				tx(2, date(2024, 8, 2), "20.46", domain.Debit),
+			},
+		},
+		{
+			name: "multi-year",
+			transactions: []domain.Transaction{
+				tx(1, date(2023, 11, 3), "250", domain.Credit),
+				tx(2, date(2023, 12, 24), "89.99", domain.Debit),
+				tx(3, date(2024, 1, 5), "1200", domain.Credit),
+				tx(4, date(2024, 1, 19), "310.4", domain.Debit),
+				tx(5, date(2024, 7, 15), "60.5", domain.Credit),
+				tx(6, date(2024, 7, 28), "10.3", domain.Debit),
+				tx(7, date(2024, 8, 2), "20.46", domain.Debit),
+				tx(8, date(2024, 8, 13), "10", domain.Credit),
+			},
+			accountBalance: "2450.8",
+		},
+	}
+
+	fixtures := make([]Fixture, len(sets))
+	for i, set := range sets {
+		summary := domain.NewSummary(set.transactions)
+		if set.history != nil {
+			summary.Comparison = domain.NewPeriodComparison(summary, set.history)
+		}
+		if set.accountBalance != "" {
+			balance := decimal.RequireFromString(set.accountBalance)
+			summary.AccountBalance = &balance
+		}
+		fixtures[i] = Fixture{
+			Name:         set.name,
+			Summary:      summary,
+			Transactions: set.transactions,
+		}
+	}
+	return fixtures
+}
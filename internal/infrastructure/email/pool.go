@@ -0,0 +1,239 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+const defaultPoolIdleTimeout = 30 * time.Second
+
+type pooledSession struct {
+	*smtpSession
+	lastUsed time.Time
+}
+
+// pooledTransport keeps up to size authenticated SMTP sessions open and reuses them
+// across messages, resetting each session with RSET before it is used again
+type pooledTransport struct {
+	dialer      *smtpTransport
+	idleTimeout time.Duration
+	idle        chan *pooledSession
+	slots       chan struct{}
+	logger      *slog.Logger
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newPooledTransport(dialer *smtpTransport, size int, idleTimeout time.Duration, logger *slog.Logger) *pooledTransport {
+	if idleTimeout <= 0 {
+		idleTimeout = defaultPoolIdleTimeout
+	}
+
+	return &pooledTransport{
+		dialer:      dialer,
+		idleTimeout: idleTimeout,
+		idle:        make(chan *pooledSession, size),
+		slots:       make(chan struct{}, size),
+		logger:      logger,
+	}
+}
+
+func (p *pooledTransport) send(ctx context.Context, from string, recipients []string, message []byte) error {
+	for {
+		session, reused, err := p.acquire(ctx)
+		if err != nil {
+			p.logger.Error("SMTP connection failed", "error", err, "host", p.dialer.config.Host)
+			return fmt.Errorf("%w: %v", domain.ErrEmailDeliveryFailed, err)
+		}
+
+		release := p.dialer.bindContext(ctx, session.conn)
+		err = deliver(session.client, from, recipients, message)
+		release()
+
+		if err == nil {
+			p.put(session)
+			return nil
+		}
+
+		var protocolErr *textproto.Error
+		if errors.As(err, &protocolErr) {
+			// The server rejected the message but the session is still usable
+			p.logger.Error("SMTP send failed", "error", err, "recipients", recipients)
+			if resetErr := session.client.Reset(); resetErr == nil {
+				p.put(session)
+			} else {
+				p.discard(session)
+			}
+			return fmt.Errorf("%w: %v", domain.ErrEmailDeliveryFailed, err)
+		}
+
+		// Only a failure before the message was handed over is safe to retry: a reused
+		// connection dropped by the server fails at MAIL or RCPT
+		var dataErr *dataError
+		p.discard(session)
+		if !reused || ctx.Err() != nil || errors.As(err, &dataErr) {
+			p.logger.Error("SMTP send failed", "error", err, "recipients", recipients)
+			return fmt.Errorf("%w: %v", domain.ErrEmailDeliveryFailed, err)
+		}
+
+		p.logger.Debug("pooled SMTP connection failed, reconnecting", "error", err)
+	}
+}
+
+// acquire returns an idle session or dials a new one when the pool has a free slot,
+// waiting for a session to be released otherwise
+func (p *pooledTransport) acquire(ctx context.Context) (*pooledSession, bool, error) {
+	for {
+		select {
+		case session := <-p.idle:
+			if p.revive(ctx, session) {
+				return session, true, nil
+			}
+			continue
+		default:
+		}
+
+		select {
+		case session := <-p.idle:
+			if p.revive(ctx, session) {
+				return session, true, nil
+			}
+		case p.slots <- struct{}{}:
+			smtpSession, err := p.dialer.connect(ctx)
+			if err != nil {
+				<-p.slots
+				return nil, false, err
+			}
+			return &pooledSession{smtpSession: smtpSession}, false, nil
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+}
+
+// revive checks that an idle session is fresh and resets it for a new message,
+// discarding it otherwise. Idle sessions are not reaped in the background: one idle for
+// longer than idleTimeout is only closed here, when it is next taken out, or by Close
+func (p *pooledTransport) revive(ctx context.Context, session *pooledSession) bool {
+	if time.Since(session.lastUsed) > p.idleTimeout {
+		p.discard(session)
+		return false
+	}
+
+	release := p.dialer.bindContext(ctx, session.conn)
+	err := session.client.Reset()
+	release()
+	if err != nil {
+		p.logger.Debug("discarding stale SMTP connection", "error", err)
+		p.discard(session)
+		return false
+	}
+
+	return true
+}
+
+// put returns a session to the pool, closing it once the pool is closed. The lock is held
+// while parking the session so Close cannot drain the pool in between; idle has room for
+// every session, so the send never blocks
+func (p *pooledTransport) put(session *pooledSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		p.discard(session)
+		return
+	}
+
+	session.lastUsed = time.Now()
+	p.idle <- session
+}
+
+func (p *pooledTransport) discard(session *pooledSession) {
+	session.client.Close()
+	<-p.slots
+}
+
+// Close quits every idle session. Sessions in use are closed when released
+func (p *pooledTransport) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	for {
+		select {
+		case session := <-p.idle:
+			session.client.Quit()
+			p.discard(session)
+		default:
+			return nil
+		}
+	}
+}
+
+// rateLimiter spaces operations evenly to allow at most perSecond operations per second
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+	}
+}
+
+// wait blocks until the next operation is allowed or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimitedTransport delays messages so that at most the configured number per second are sent
+type rateLimitedTransport struct {
+	transport
+	limiter *rateLimiter
+}
+
+func (t *rateLimitedTransport) send(ctx context.Context, from string, recipients []string, message []byte) error {
+	if err := t.limiter.wait(ctx); err != nil {
+		return fmt.Errorf("waiting for rate limiter: %w", err)
+	}
+	return t.transport.send(ctx, from, recipients, message)
+}
+
+// Close closes the underlying transport when it holds resources
+func (t *rateLimitedTransport) Close() error {
+	if closer, ok := t.transport.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package email
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPool(server *fakeSMTPServer, size int) *pooledTransport {
+	dialer := &smtpTransport{
+		config: SMTPConfig{
+			Host:          "127.0.0.1",
+			Port:          server.port(),
+			Username:      "user",
+			Password:      "secret",
+			TLSMode:       TLSNone,
+			AuthMechanism: AuthPlain,
+			Timeout:       5 * time.Second,
+		},
+		logger: discardLogger(),
+	}
+	return newPooledTransport(dialer, size, time.Minute, discardLogger())
+}
+
+func TestPooledTransportReusesConnection(t *testing.T) {
+	server := newFakeSMTPServer(t, "PLAIN")
+	pool := newTestPool(server, 1)
+	defer pool.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := pool.send(context.Background(), "noreply@stori.com", []string{"user@example.com"}, []byte("body\r\n")); err != nil {
+			t.Fatalf("send %d: unexpected error: %v", i, err)
+		}
+	}
+
+	commands, messages, logins, connections := server.snapshot()
+	if len(messages) != 3 {
+		t.Errorf("messages = %d, want 3", len(messages))
+	}
+	if connections != 1 {
+		t.Errorf("connections = %d, want 1", connections)
+	}
+	if len(logins) != 1 {
+		t.Errorf("logins = %d, want 1", len(logins))
+	}
+	if resets := strings.Count(strings.Join(commands, " "), "RSET"); resets != 2 {
+		t.Errorf("RSET commands = %d, want 2", resets)
+	}
+}
+
+func TestPooledTransportReconnectsDroppedConnection(t *testing.T) {
+	server := newFakeSMTPServer(t, "PLAIN")
+	server.dropAfterMessage = true
+	pool := newTestPool(server, 1)
+	defer pool.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := pool.send(context.Background(), "noreply@stori.com", []string{"user@example.com"}, []byte("body\r\n")); err != nil {
+			t.Fatalf("send %d: unexpected error: %v", i, err)
+		}
+	}
+
+	_, messages, _, connections := server.snapshot()
+	if len(messages) != 2 {
+		t.Errorf("messages = %d, want 2", len(messages))
+	}
+	if connections != 2 {
+		t.Errorf("connections = %d, want 2", connections)
+	}
+}
+
+func TestPooledTransportDoesNotResendAfterData(t *testing.T) {
+	server := newFakeSMTPServer(t, "PLAIN")
+	pool := newTestPool(server, 1)
+	defer pool.Close()
+
+	ctx := context.Background()
+	if err := pool.send(ctx, "noreply@stori.com", []string{"user@example.com"}, []byte("body\r\n")); err != nil {
+		t.Fatalf("first send: unexpected error: %v", err)
+	}
+
+	// The server receives the message on the reused connection but drops it before replying
+	server.mu.Lock()
+	server.dropBeforeReply = true
+	server.mu.Unlock()
+	if err := pool.send(ctx, "noreply@stori.com", []string{"user@example.com"}, []byte("body\r\n")); err == nil {
+		t.Fatal("send succeeded without the server's reply")
+	}
+
+	_, messages, _, connections := server.snapshot()
+	if len(messages) != 2 {
+		t.Errorf("messages = %d, want 2 without a resend", len(messages))
+	}
+	if connections != 1 {
+		t.Errorf("connections = %d, want 1", connections)
+	}
+}
+
+func TestPooledTransportClosesSessionsReleasedAfterClose(t *testing.T) {
+	server := newFakeSMTPServer(t, "PLAIN")
+	pool := newTestPool(server, 1)
+
+	session, _, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// A session in use while the pool closed is closed when released, not parked
+	pool.put(session)
+	if len(pool.idle) != 0 {
+		t.Errorf("idle sessions = %d, want none in a closed pool", len(pool.idle))
+	}
+	if len(pool.slots) != 0 {
+		t.Errorf("slots in use = %d, want none", len(pool.slots))
+	}
+}
+
+func TestRateLimiterSpacesOperations(t *testing.T) {
+	limiter := newRateLimiter(20) // one every 50ms
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("4 operations at 20/s took %s, want at least 150ms", elapsed)
+	}
+}
@@ -0,0 +1,293 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/chart"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+// transport delivers a fully rendered RFC 5322 message
+type transport interface {
+	send(ctx context.Context, from string, recipients []string, message []byte) error
+}
+
+type emailService struct {
+	config    SMTPConfig
+	transport transport
+	templates *TemplateStore
+	logger    *slog.Logger
+}
+
+// NewEmailService creates the email service selected by config.Driver (smtp or file)
+// rendering with the given templates. A nil store uses the embedded templates
+func NewEmailService(config SMTPConfig, templates *TemplateStore, logger *slog.Logger) (services.EmailService, error) {
+	var t transport
+	switch config.Driver {
+	case DriverSMTP, "":
+		t = newSMTPTransport(config, logger)
+	case DriverFile:
+		t = &fileTransport{directory: config.FileDirectory, logger: logger}
+	default:
+		return nil, fmt.Errorf("unknown email driver %q", config.Driver)
+	}
+
+	service := newEmailService(config, t, logger)
+	if templates != nil {
+		service.templates = templates
+	}
+	return service, nil
+}
+
+func newEmailService(config SMTPConfig, t transport, logger *slog.Logger) *emailService {
+	return &emailService{
+		config:    config,
+		transport: t,
+		logger:    logger,
+	}
+}
+
+// Close releases the resources held by the transport, such as pooled SMTP connections
+func (s *emailService) Close() error {
+	if closer, ok := s.transport.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// SendSummary sends an email summary to the recipient
+func (s *emailService) SendSummary(ctx context.Context, recipient string, summary *domain.Summary, transactions []domain.Transaction) error {
+	s.logger.Info("sending email summary", "recipient", recipient)
+
+	locale := s.config.LocaleFor(recipient)
+
+	templates, err := s.templateStore()
+	if err != nil {
+		return fmt.Errorf("rendering email template: %w", err)
+	}
+
+	// The chart travels as an inline part referenced by Content-ID, since most
+	// mail clients block data: URIs in images
+	png, err := chart.MonthlyActivity(summary)
+	if err != nil {
+		return fmt.Errorf("rendering chart: %w", err)
+	}
+	var inline []attachment
+	var chartSrc template.URL
+	if png != nil {
+		inline = append(inline, attachment{
+			filename:    "activity.png",
+			contentType: "image/png",
+			contentID:   chartContentID,
+			data:        png,
+		})
+		chartSrc = template.URL("cid:" + chartContentID)
+	}
+
+	htmlBody, err := templates.render(summary, s.config.renderOptions(recipient), chartSrc)
+	if err != nil {
+		return fmt.Errorf("rendering email template: %w", err)
+	}
+
+	attachments, err := buildAttachments(s.config.attachmentsFor(recipient), recipient, locale, summary, transactions)
+	if err != nil {
+		return fmt.Errorf("building attachments: %w", err)
+	}
+
+	message, err := s.createEmailMessage(recipient, locale.T("email.subject"), htmlBody, inline, attachments)
+	if err != nil {
+		return fmt.Errorf("creating email message: %w", err)
+	}
+
+	data := []byte(message)
+	if s.config.DKIM.Enabled() {
+		if data, err = signDKIM(s.config.DKIM, data); err != nil {
+			return fmt.Errorf("DKIM signing email: %w", err)
+		}
+	}
+
+	if err := s.transport.send(ctx, s.config.From, []string{recipient}, data); err != nil {
+		return fmt.Errorf("sending email to %s: %w", recipient, err)
+	}
+
+	s.logger.Info("email summary sent successfully", "recipient", recipient, "attachments", len(attachments))
+	return nil
+}
+
+// RenderTemplate renders the email template with summary data in the recipient's locale
+func (s *emailService) RenderTemplate(recipient string, summary *domain.Summary) (string, error) {
+	templates, err := s.templateStore()
+	if err != nil {
+		return "", err
+	}
+	return templates.Render(summary, s.config.renderOptions(recipient))
+}
+
+func (s *emailService) templateStore() (*TemplateStore, error) {
+	if s.templates != nil {
+		return s.templates, nil
+	}
+	return defaultTemplates()
+}
+
+func (s *emailService) createEmailMessage(to, subject, htmlBody string, inline, attachments []attachment) (string, error) {
+	headers := make(map[string]string)
+	headers["From"] = s.config.From
+	headers["To"] = to
+	headers["Subject"] = mime.QEncoding.Encode("utf-8", subject)
+	headers["Date"] = time.Now().Format(time.RFC1123Z)
+	headers["Message-ID"] = newMessageID(s.config.From)
+	headers["MIME-Version"] = "1.0"
+
+	contentHeader, content, err := htmlContent(htmlBody, inline)
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	if len(attachments) == 0 {
+		for key := range contentHeader {
+			headers[key] = contentHeader.Get(key)
+		}
+		body.Write(content)
+	} else {
+		writer := multipart.NewWriter(&body)
+		headers["Content-Type"] = "multipart/mixed; boundary=" + writer.Boundary()
+
+		htmlPart, err := writer.CreatePart(contentHeader)
+		if err != nil {
+			return "", fmt.Errorf("creating HTML part: %w", err)
+		}
+		htmlPart.Write(content)
+
+		for _, a := range attachments {
+			part, err := writer.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {a.contentType},
+				"Content-Transfer-Encoding": {"base64"},
+				"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.filename})},
+			})
+			if err != nil {
+				return "", fmt.Errorf("creating attachment part %s: %w", a.filename, err)
+			}
+			part.Write(encodeBase64Lines(a.data))
+		}
+
+		if err := writer.Close(); err != nil {
+			return "", fmt.Errorf("closing multipart message: %w", err)
+		}
+	}
+
+	var message strings.Builder
+	for key, value := range headers {
+		message.WriteString(fmt.Sprintf("%s: %s\r\n", key, value))
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.String(), nil
+}
+
+// htmlContent returns the headers and content of the HTML body, wrapped in a
+// multipart/related entity together with its inline parts when there are any (RFC 2387).
+// The HTML is quoted-printable, so the message is 7-bit and relays never need to rewrite
+// it, which would break its DKIM signature
+func htmlContent(htmlBody string, inline []attachment) (textproto.MIMEHeader, []byte, error) {
+	htmlHeader := textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}
+	html, err := encodeQuotedPrintable(htmlBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding HTML body: %w", err)
+	}
+	if len(inline) == 0 {
+		return htmlHeader, html, nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	htmlPart, err := writer.CreatePart(htmlHeader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating HTML part: %w", err)
+	}
+	htmlPart.Write(html)
+
+	for _, a := range inline {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-ID":                {"<" + a.contentID + ">"},
+			"Content-Disposition":       {mime.FormatMediaType("inline", map[string]string{"filename": a.filename})},
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating inline part %s: %w", a.filename, err)
+		}
+		part.Write(encodeBase64Lines(a.data))
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, nil, fmt.Errorf("closing related part: %w", err)
+	}
+
+	contentType := mime.FormatMediaType("multipart/related", map[string]string{
+		"type":     "text/html",
+		"boundary": writer.Boundary(),
+	})
+	return textproto.MIMEHeader{"Content-Type": {contentType}}, body.Bytes(), nil
+}
+
+// encodeQuotedPrintable encodes text as quoted-printable with CRLF line breaks (RFC 2045)
+func encodeQuotedPrintable(text string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := quotedprintable.NewWriter(&buf)
+	if _, err := writer.Write([]byte(text)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newMessageID generates a unique Message-ID in the sender's domain
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+
+	random := make([]byte, 12)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
+
+// encodeBase64Lines encodes data as base64 wrapped at 76 characters per line (RFC 2045)
+func encodeBase64Lines(data []byte) []byte {
+	const lineLength = 76
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var buf bytes.Buffer
+	for len(encoded) > lineLength {
+		buf.WriteString(encoded[:lineLength])
+		buf.WriteString("\r\n")
+		encoded = encoded[lineLength:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
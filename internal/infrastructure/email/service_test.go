@@ -0,0 +1,184 @@
+package email
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func testTransactions() []domain.Transaction {
+	return []domain.Transaction{
+		{ID: 1, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("60.5"), Type: domain.Credit},
+		{ID: 2, Date: time.Date(2024, 7, 28, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("10.3"), Type: domain.Debit},
+		{ID: 3, Date: time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("20.46"), Type: domain.Debit},
+	}
+}
+
+func TestMemoryEmailServiceSendSummary(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          SMTPConfig
+		wantAttachments []string
+	}{
+		{
+			name:   "no attachments",
+			config: SMTPConfig{From: "noreply@stori.com"},
+		},
+		{
+			name:            "default attachments",
+			config:          SMTPConfig{From: "noreply@stori.com", Attachments: []string{AttachmentCSV, AttachmentPDF}},
+			wantAttachments: []string{"text/csv", "application/pdf"},
+		},
+		{
+			name: "account override",
+			config: SMTPConfig{
+				From:               "noreply@stori.com",
+				Attachments:        []string{AttachmentCSV, AttachmentPDF},
+				AccountAttachments: map[string][]string{"user@example.com": {AttachmentPDF}},
+			},
+			wantAttachments: []string{"application/pdf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailbox := NewMailbox()
+			service := NewMailboxEmailService(tt.config, mailbox, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			transactions := testTransactions()
+			err := service.SendSummary(context.Background(), "User@Example.com", domain.NewSummary(transactions), transactions)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			messages := mailbox.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(messages))
+			}
+
+			msg, err := mail.ReadMessage(strings.NewReader(string(messages[0].Data)))
+			if err != nil {
+				t.Fatalf("parsing message: %v", err)
+			}
+			if got := msg.Header.Get("To"); got != "User@Example.com" {
+				t.Errorf("To = %s, want User@Example.com", got)
+			}
+
+			mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			if err != nil {
+				t.Fatalf("parsing content type: %v", err)
+			}
+
+			if len(tt.wantAttachments) == 0 {
+				if mediaType != "multipart/related" {
+					t.Errorf("Content-Type = %s, want multipart/related", mediaType)
+				}
+				return
+			}
+
+			reader := multipart.NewReader(msg.Body, params["boundary"])
+			var attachmentTypes []string
+			for {
+				part, err := reader.NextPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("reading part: %v", err)
+				}
+				if strings.HasPrefix(part.Header.Get("Content-Disposition"), "attachment") {
+					partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+					attachmentTypes = append(attachmentTypes, partType)
+				}
+			}
+
+			if strings.Join(attachmentTypes, ",") != strings.Join(tt.wantAttachments, ",") {
+				t.Errorf("attachments = %v, want %v", attachmentTypes, tt.wantAttachments)
+			}
+		})
+	}
+}
+
+func TestSendSummaryInlineChart(t *testing.T) {
+	mailbox := NewMailbox()
+	service := NewMailboxEmailService(SMTPConfig{From: "noreply@stori.com"}, mailbox, nil, discardLogger())
+
+	transactions := testTransactions()
+	if err := service.SendSummary(context.Background(), "user@example.com", domain.NewSummary(transactions), transactions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(mailbox.Messages()[0].Data)))
+	if err != nil {
+		t.Fatalf("parsing message: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("parsing content type: %v", err)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	htmlPart, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("reading HTML part: %v", err)
+	}
+	html, _ := io.ReadAll(htmlPart)
+	if !strings.Contains(string(html), `src="cid:`+chartContentID+`"`) {
+		t.Error("HTML body does not reference the inline chart")
+	}
+
+	imagePart, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("reading chart part: %v", err)
+	}
+	if got := imagePart.Header.Get("Content-Type"); got != "image/png" {
+		t.Errorf("chart Content-Type = %s, want image/png", got)
+	}
+	if got := imagePart.Header.Get("Content-ID"); got != "<"+chartContentID+">" {
+		t.Errorf("chart Content-ID = %s, want <%s>", got, chartContentID)
+	}
+}
+
+func TestSendSummaryWithoutTransactionsHasNoChart(t *testing.T) {
+	mailbox := NewMailbox()
+	service := NewMailboxEmailService(SMTPConfig{From: "noreply@stori.com"}, mailbox, nil, discardLogger())
+
+	if err := service.SendSummary(context.Background(), "user@example.com", domain.NewSummary(nil), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(mailbox.Messages()[0].Data)))
+	if err != nil {
+		t.Fatalf("parsing message: %v", err)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(msg.Header.Get("Content-Type")); mediaType != "text/html" {
+		t.Errorf("Content-Type = %s, want text/html", mediaType)
+	}
+}
+
+func TestParseAccountAttachments(t *testing.T) {
+	overrides, err := ParseAccountAttachments("Alice@Example.com=csv,pdf; bob@example.com=none")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := overrides["alice@example.com"]; len(got) != 2 {
+		t.Errorf("alice attachments = %v, want [csv pdf]", got)
+	}
+	if got, ok := overrides["bob@example.com"]; !ok || len(got) != 0 {
+		t.Errorf("bob attachments = %v, want empty override", got)
+	}
+
+	if _, err := ParseAccountAttachments("alice@example.com=zip"); err == nil {
+		t.Error("expected error for unknown attachment type")
+	}
+}
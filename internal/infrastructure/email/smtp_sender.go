@@ -2,90 +2,342 @@ package email
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/smtp"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/NahuelDT/stori-challenge/internal/domain"
-	"github.com/NahuelDT/stori-challenge/internal/services"
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
 )
 
+const (
+	// TLSStartTLS upgrades the connection with STARTTLS and fails if the server does not offer it
+	TLSStartTLS = "starttls"
+	// TLSStartTLSOptional upgrades with STARTTLS when the server offers it
+	TLSStartTLSOptional = "starttls-optional"
+	// TLSImplicit connects over TLS from the start (SMTPS, usually port 465)
+	TLSImplicit = "implicit"
+	// TLSNone never encrypts the connection, for local relays
+	TLSNone = "none"
+)
+
+const (
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+	AuthNone    = "none"
+)
+
+// DefaultAuthMechanism is plain when a username is configured and none otherwise, as
+// relays that take mail without credentials usually do not offer TLS either
+func DefaultAuthMechanism(username string) string {
+	if username == "" {
+		return AuthNone
+	}
+	return AuthPlain
+}
+
+const defaultSMTPTimeout = 30 * time.Second
+
 type SMTPConfig struct {
 	Host     string
 	Port     string
 	Username string
 	Password string
 	From     string
+
+	// TLSMode is one of starttls, starttls-optional, implicit or none; empty is
+	// starttls-optional
+	TLSMode string
+	// AuthMechanism is one of plain, login, cram-md5 or none; empty is plain with a
+	// username and none without
+	AuthMechanism string
+	// CAFile is an optional PEM bundle trusted in addition to the system roots
+	CAFile string
+	// Timeout bounds dialing and the whole SMTP session
+	Timeout time.Duration
+
+	// PoolSize is the number of SMTP connections kept open and reused across
+	// messages; zero opens a new connection per message
+	PoolSize int
+	// PoolIdleTimeout is how long a pooled connection may sit unused. A stale connection
+	// is closed when it is next taken out rather than in the background
+	PoolIdleTimeout time.Duration
+	// RateLimit caps the number of messages sent per second; zero means unlimited
+	RateLimit float64
+
+	// DKIM signs outgoing messages when a key is configured
+	DKIM DKIMConfig
+
+	// TemplateDirectory overrides the embedded email templates file by file
+	TemplateDirectory string
+	// TemplateReload reloads templates when files in TemplateDirectory change
+	TemplateReload bool
+	// Statistics adds the distribution and largest transactions section to the email
+	Statistics bool
+
+	// Locale is the default language of the emails
+	Locale i18n.Locale
+	// AccountLocales overrides Locale per recipient email
+	AccountLocales map[string]i18n.Locale
+
+	// Driver selects how messages are delivered: smtp or file
+	Driver string
+	// FileDirectory is where the file driver writes .eml messages
+	FileDirectory string
+
+	// Attachments lists the attachment kinds (csv, pdf) sent by default
+	Attachments []string
+	// AccountAttachments overrides Attachments per recipient email
+	AccountAttachments map[string][]string
 }
 
-type smtpEmailService struct {
+type smtpTransport struct {
 	config SMTPConfig
 	logger *slog.Logger
 }
 
-// NewSMTPEmailService creates a new SMTP email service
-func NewSMTPEmailService(config SMTPConfig, logger *slog.Logger) services.EmailService {
-	return &smtpEmailService{
-		config: config,
-		logger: logger,
+// newSMTPTransport builds the SMTP transport, pooled and rate limited when configured
+func newSMTPTransport(config SMTPConfig, logger *slog.Logger) transport {
+	dialer := &smtpTransport{config: config, logger: logger}
+
+	var t transport = dialer
+	if config.PoolSize > 0 {
+		t = newPooledTransport(dialer, config.PoolSize, config.PoolIdleTimeout, logger)
+	}
+	if config.RateLimit > 0 {
+		t = &rateLimitedTransport{transport: t, limiter: newRateLimiter(config.RateLimit)}
 	}
+
+	return t
 }
 
-// SendSummary sends an email summary to the recipient
-func (s *smtpEmailService) SendSummary(ctx context.Context, recipient string, summary *domain.Summary) error {
-	s.logger.Info("sending email summary", "recipient", recipient)
+// renderOptions returns how the summary email is rendered for a recipient
+func (c SMTPConfig) renderOptions(recipient string) RenderOptions {
+	return RenderOptions{
+		Locale:     c.LocaleFor(recipient),
+		Statistics: c.Statistics,
+	}
+}
+
+// LocaleFor returns the locale configured for a recipient
+func (c SMTPConfig) LocaleFor(recipient string) i18n.Locale {
+	if locale, ok := c.AccountLocales[strings.ToLower(recipient)]; ok {
+		return locale
+	}
+	if c.Locale == "" {
+		return i18n.DefaultLocale
+	}
+	return c.Locale
+}
 
-	htmlBody, err := s.RenderTemplate(summary)
+// smtpSession is an established, encrypted and authenticated SMTP connection
+type smtpSession struct {
+	client *smtp.Client
+	conn   net.Conn
+}
+
+func (t *smtpTransport) send(ctx context.Context, from string, recipients []string, message []byte) error {
+	session, err := t.connect(ctx)
 	if err != nil {
-		return fmt.Errorf("rendering email template: %w", err)
+		t.logger.Error("SMTP connection failed", "error", err, "host", t.config.Host, "port", t.config.Port)
+		return fmt.Errorf("%w: %v", domain.ErrEmailDeliveryFailed, err)
 	}
+	defer session.client.Close()
 
-	message := s.createEmailMessage(recipient, "Stori - Your Account Summary", htmlBody)
+	release := t.bindContext(ctx, session.conn)
+	defer release()
+
+	if err := deliver(session.client, from, recipients, message); err != nil {
+		t.logger.Error("SMTP send failed", "error", err, "recipients", recipients)
+		return fmt.Errorf("%w: %v", domain.ErrEmailDeliveryFailed, err)
+	}
 
-	if err := s.sendEmail(recipient, message); err != nil {
-		return fmt.Errorf("sending email to %s: %w", recipient, err)
+	if err := session.client.Quit(); err != nil {
+		t.logger.Debug("SMTP quit failed", "error", err)
 	}
 
-	s.logger.Info("email summary sent successfully", "recipient", recipient)
 	return nil
 }
 
-// RenderTemplate renders the email template with summary data
-func (s *smtpEmailService) RenderTemplate(summary *domain.Summary) (string, error) {
-	return RenderEmailTemplate(summary)
+// connect dials the server and returns a session that is encrypted and authenticated
+// according to the configuration
+func (t *smtpTransport) connect(ctx context.Context) (*smtpSession, error) {
+	addr := net.JoinHostPort(t.config.Host, t.config.Port)
+	t.logger.Debug("connecting to SMTP server", "host", t.config.Host, "port", t.config.Port, "tls_mode", t.config.TLSMode)
+
+	tlsConfig, err := t.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: t.timeout()}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dialing %s: %w", addr, err)
+	}
+
+	release := t.bindContext(ctx, conn)
+	defer release()
+
+	client, err := t.handshake(ctx, conn, tlsConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &smtpSession{client: client, conn: conn}, nil
+}
+
+// bindContext makes blocking I/O on conn fail once ctx is cancelled, its deadline passes
+// or the configured timeout elapses. The returned function unbinds the connection
+func (t *smtpTransport) bindContext(ctx context.Context, conn net.Conn) func() {
+	deadline := time.Now().Add(t.timeout())
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+
+	return func() {
+		stop()
+		conn.SetDeadline(time.Time{})
+	}
 }
 
-func (s *smtpEmailService) createEmailMessage(to, subject, htmlBody string) string {
-	headers := make(map[string]string)
-	headers["From"] = s.config.From
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=utf-8"
+func (t *smtpTransport) timeout() time.Duration {
+	if t.config.Timeout <= 0 {
+		return defaultSMTPTimeout
+	}
+	return t.config.Timeout
+}
 
-	var message strings.Builder
-	for key, value := range headers {
-		message.WriteString(fmt.Sprintf("%s: %s\r\n", key, value))
+func (t *smtpTransport) handshake(ctx context.Context, conn net.Conn, tlsConfig *tls.Config) (*smtp.Client, error) {
+	if t.config.TLSMode == TLSImplicit {
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("TLS handshake: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	client, err := smtp.NewClient(conn, t.config.Host)
+	if err != nil {
+		return nil, fmt.Errorf("creating SMTP client: %w", err)
+	}
+
+	switch t.config.TLSMode {
+	case TLSStartTLS, TLSStartTLSOptional, "":
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return nil, fmt.Errorf("starting TLS: %w", err)
+			}
+		} else if t.config.TLSMode == TLSStartTLS {
+			return nil, fmt.Errorf("server %s does not support STARTTLS", t.config.Host)
+		}
+	}
+
+	auth, err := t.auth()
+	if err != nil {
+		return nil, err
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return nil, fmt.Errorf("server %s does not support authentication", t.config.Host)
+		}
+		if err := client.Auth(auth); err != nil {
+			return nil, fmt.Errorf("authenticating: %w", err)
+		}
 	}
-	message.WriteString("\r\n")
-	message.WriteString(htmlBody)
 
-	return message.String()
+	return client, nil
 }
 
-func (s *smtpEmailService) sendEmail(recipient, message string) error {
-	auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+func (t *smtpTransport) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: t.config.Host,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if t.config.CAFile != "" {
+		pem, err := os.ReadFile(t.config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file %s: %w", t.config.CAFile, err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", t.config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+func (t *smtpTransport) auth() (smtp.Auth, error) {
+	mechanism := t.config.AuthMechanism
+	if mechanism == "" {
+		mechanism = DefaultAuthMechanism(t.config.Username)
+	}
+
+	switch mechanism {
+	case AuthPlain:
+		return smtp.PlainAuth("", t.config.Username, t.config.Password, t.config.Host), nil
+	case AuthLogin:
+		return LoginAuth(t.config.Username, t.config.Password, t.config.Host), nil
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(t.config.Username, t.config.Password), nil
+	case AuthNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown SMTP auth mechanism %q", t.config.AuthMechanism)
+	}
+}
 
-	addr := s.config.Host + ":" + s.config.Port
-	recipients := []string{recipient}
+// dataError is a delivery failure after the server accepted DATA. The message may have
+// been delivered, so it must not be sent again
+type dataError struct {
+	err error
+}
 
-	s.logger.Debug("connecting to SMTP server", "host", s.config.Host, "port", s.config.Port)
+func (e *dataError) Error() string { return e.err.Error() }
 
-	err := smtp.SendMail(addr, auth, s.config.From, recipients, []byte(message))
+func (e *dataError) Unwrap() error { return e.err }
+
+// deliver sends a single message over an established session
+func deliver(client *smtp.Client, from string, recipients []string, message []byte) error {
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("MAIL FROM: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("RCPT TO %s: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
 	if err != nil {
-		s.logger.Error("SMTP send failed", "error", err, "recipient", recipient)
-		return domain.ErrEmailDeliveryFailed
+		return fmt.Errorf("DATA: %w", err)
+	}
+	if _, err := writer.Write(message); err != nil {
+		writer.Close()
+		return &dataError{fmt.Errorf("writing message: %w", err)}
+	}
+	if err := writer.Close(); err != nil {
+		return &dataError{fmt.Errorf("finishing message: %w", err)}
 	}
 
 	return nil
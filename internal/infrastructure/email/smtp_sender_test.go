@@ -0,0 +1,272 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer is a minimal plaintext SMTP server recording the commands it receives
+type fakeSMTPServer struct {
+	listener net.Listener
+	authMech string
+	// dropAfterMessage closes the connection after every accepted message
+	dropAfterMessage bool
+	// dropBeforeReply closes the connection after receiving a message, without replying
+	dropBeforeReply bool
+
+	mu          sync.Mutex
+	commands    []string
+	messages    []string
+	connections int
+	logins      []string
+}
+
+func newFakeSMTPServer(t *testing.T, authMech string) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+
+	server := &fakeSMTPServer{listener: listener, authMech: authMech}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+func (s *fakeSMTPServer) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	readLine := func() (string, error) {
+		line, err := reader.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := readLine()
+		if err != nil {
+			return
+		}
+
+		verb := strings.ToUpper(strings.Fields(line + " x")[0])
+		s.mu.Lock()
+		s.commands = append(s.commands, verb)
+		s.mu.Unlock()
+
+		switch verb {
+		case "EHLO", "HELO":
+			if s.authMech != "" {
+				reply("250-fake")
+				reply("250 AUTH " + s.authMech)
+			} else {
+				reply("250 fake")
+			}
+		case "AUTH":
+			username := ""
+			if s.authMech == "LOGIN" {
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+				user, _ := readLine()
+				decoded, _ := base64.StdEncoding.DecodeString(user)
+				username = string(decoded)
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+				readLine()
+			} else {
+				fields := strings.Fields(line)
+				decoded, _ := base64.StdEncoding.DecodeString(fields[len(fields)-1])
+				username = strings.Split(string(decoded), "\x00")[1]
+			}
+			s.mu.Lock()
+			s.logins = append(s.logins, username)
+			s.mu.Unlock()
+			reply("235 authenticated")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var body strings.Builder
+			for {
+				dataLine, err := readLine()
+				if err != nil {
+					return
+				}
+				if dataLine == "." {
+					break
+				}
+				body.WriteString(dataLine + "\n")
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, body.String())
+			dropBeforeReply := s.dropBeforeReply
+			s.mu.Unlock()
+			if dropBeforeReply {
+				return
+			}
+			reply("250 queued")
+			if s.dropAfterMessage {
+				return
+			}
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) snapshot() (commands, messages, logins []string, connections int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...), append([]string(nil), s.messages...), append([]string(nil), s.logins...), s.connections
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestSMTPTransportAuthMechanisms(t *testing.T) {
+	tests := []struct {
+		name       string
+		serverAuth string
+		mechanism  string
+		username   string
+		wantLogin  bool
+	}{
+		{name: "plain", serverAuth: "PLAIN", mechanism: AuthPlain, username: "user", wantLogin: true},
+		{name: "login", serverAuth: "LOGIN", mechanism: AuthLogin, username: "user", wantLogin: true},
+		{name: "no auth", serverAuth: "", mechanism: AuthNone, username: "user", wantLogin: false},
+		{name: "default with username", serverAuth: "PLAIN", mechanism: "", username: "user", wantLogin: true},
+		{name: "default without username", serverAuth: "", mechanism: "", username: "", wantLogin: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, tt.serverAuth)
+			transport := &smtpTransport{
+				config: SMTPConfig{
+					Host:          "127.0.0.1",
+					Port:          server.port(),
+					Username:      tt.username,
+					Password:      "secret",
+					TLSMode:       TLSNone,
+					AuthMechanism: tt.mechanism,
+					Timeout:       5 * time.Second,
+				},
+				logger: discardLogger(),
+			}
+
+			err := transport.send(context.Background(), "noreply@stori.com", []string{"user@example.com"}, []byte("Subject: hi\r\n\r\nbody\r\n"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, messages, logins, _ := server.snapshot()
+			if len(messages) != 1 {
+				t.Fatalf("server received %d messages, want 1", len(messages))
+			}
+			if tt.wantLogin && (len(logins) != 1 || logins[0] != "user") {
+				t.Errorf("logins = %v, want [user]", logins)
+			}
+			if !tt.wantLogin && len(logins) != 0 {
+				t.Errorf("logins = %v, want none", logins)
+			}
+		})
+	}
+}
+
+func TestSMTPTransportStartTLSModes(t *testing.T) {
+	// The fake server does not offer STARTTLS: only starttls requires it
+	tests := []struct {
+		mode    string
+		wantErr bool
+	}{
+		{mode: TLSStartTLS, wantErr: true},
+		{mode: TLSStartTLSOptional, wantErr: false},
+		{mode: "", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			server := newFakeSMTPServer(t, "")
+			transport := &smtpTransport{
+				config: SMTPConfig{
+					Host:          "127.0.0.1",
+					Port:          server.port(),
+					TLSMode:       tt.mode,
+					AuthMechanism: AuthNone,
+				},
+				logger: discardLogger(),
+			}
+
+			err := transport.send(context.Background(), "noreply@stori.com", []string{"user@example.com"}, []byte("body\r\n"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSMTPTransportHonorsContext(t *testing.T) {
+	// A listener that accepts connections but never greets the client
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	transport := &smtpTransport{
+		config: SMTPConfig{Host: "127.0.0.1", Port: port, TLSMode: TLSNone, AuthMechanism: AuthNone},
+		logger: discardLogger(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := transport.send(ctx, "noreply@stori.com", []string{"user@example.com"}, []byte("body\r\n")); err == nil {
+		t.Fatal("expected error for unresponsive server")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("send took %s, want it bounded by the context deadline", elapsed)
+	}
+}
@@ -2,271 +2,312 @@ package email
 
 import (
 	"bytes"
+	"context"
+	"embed"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"html/template"
-	"sort"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/chart"
+	"github.com/fsnotify/fsnotify"
+	"github.com/shopspring/decimal"
 )
 
-const emailTemplate = `<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Your Storicard Monthly Snapshot</title>
-    <style>
-        body {
-            font-family: 'Inter', Helvetica, Arial, sans-serif;
-            line-height: 1.6;
-            color: #333333;
-            max-width: 600px;
-            margin: 0 auto;
-            padding: 20px;
-            background-color: #F0F0F5;
-        }
-        .container {
-            background-color: #FFFFFF;
-            padding: 35px;
-            border-radius: 12px;
-            box-shadow: 0 4px 15px rgba(0,0,0,0.08);
-        }
-        .header {
-            text-align: center;
-            border-bottom: 1px solid #E0E0E0;
-            padding-bottom: 25px;
-            margin-bottom: 30px;
-        }
-        .logo img {
-            width: 100%;
-            display: block;
-            height: auto;
-            max-height: 220px;
-            margin-bottom: 15px;
-            object-fit: cover;
-        }
-        .title {
-            font-size: 26px;
-            font-weight: 600;
-            color: #0E5858;
-            margin-bottom: 10px;
-        }
-        .greeting {
-            font-size: 16px;
-            color: #555555;
-            margin-bottom: 30px;
-            text-align: center;
-        }
-        .balance-section {
-            text-align: center;
-            margin-bottom: 35px;
-        }
-        .balance-label {
-            font-size: 16px;
-            color: #555555;
-            margin-bottom: 8px;
-        }
-        .balance-amount {
-            font-size: 36px;
-            font-weight: 700;
-            color: #0E5858;
-            padding: 15px;
-            background-color: #F8F9FA;
-            border-radius: 8px;
-            display: inline-block;
-        }
-        .section {
-            margin: 30px 0;
-        }
-        .section-title {
-            font-size: 20px;
-            font-weight: 600;
-            color: #0E5858;
-            margin-bottom: 20px;
-            border-bottom: 1px solid #DCDCDC;
-            padding-bottom: 12px;
-        }
-        .transaction-item, .average-item {
-            display: flex;
-            justify-content: space-between;
-            align-items: center;
-            padding: 16px 8px;
-            border-bottom: 1px solid #E9E9E9;
-            font-size: 15px;
-        }
-        .transaction-item:last-child, .average-item:last-child {
-            border-bottom: none;
-        }
-        .item-label {
-            color: #4A4A4A;
-            margin-right: 10px;
-        }
-        .item-value {
-            font-weight: 700;
-            color: #0E5858;
-        }
-        .credit {
-            color: #1A7A7A;
-            font-weight: 700;
-        }
-        .debit {
-            color: #D32F2F;
-            font-weight: 700;
-        }
-        .footer {
-            text-align: center;
-            margin-top: 40px;
-            padding-top: 25px;
-            border-top: 1px solid #E0E0E0;
-            color: #777777;
-            font-size: 13px;
-        }
-        .footer p {
-            margin: 5px 0;
-        }
-        .footer a {
-            color: #0E5858;
-            text-decoration: none;
-        }
-        .footer a:hover {
-            text-decoration: underline;
-        }
-        .no-transactions {
-            text-align: center;
-            color: #666;
-            font-style: italic;
-            padding: 25px;
-            background-color: #F8F9FA;
-            border-radius: 8px;
-            margin: 30px 0;
-        }
-        @media screen and (max-width: 480px) {
-            .container {
-                padding: 20px;
-            }
-            .title {
-                font-size: 22px;
-            }
-            .balance-amount {
-                font-size: 28px;
-            }
-            .section-title {
-                font-size: 18px;
-            }
-            .transaction-item, .average-item {
-                font-size: 14px;
-                padding: 12px 4px;
-            }
-        }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <div class="logo">
-                <img src="https://stori-challenge-nahue.s3.sa-east-1.amazonaws.com/stori-bg-1.avif" alt="Storicard Logo">
-            </div>
-            <div class="title">Your Monthly Stori Snapshot</div>
-        </div>
-
-        <div class="greeting">
-            Hi! Here's a summary of your account activity this month.
-        </div>
-        
-        <div class="balance-section">
-            <div class="balance-label">Your Current Balance</div>
-            <div class="balance-amount">${{.TotalBalance.StringFixed 2}}</div>
-        </div>
-        
-        {{if .HasTransactions}}
-        <div class="section">
-            <div class="section-title">📈 Monthly Transactions Overview</div>
-            {{range .SortedMonthlyTransactions}}
-            <div class="transaction-item">
-                <span class="item-label">Transactions in {{.Month}}:</span>
-                <span class="item-value"><strong>{{.Count}}</strong></span>
-            </div>
-            {{end}}
-        </div>
-        
-        <div class="section">
-            <div class="section-title">📊 Average Transaction Values</div>
-            {{if not .AverageCredit.IsZero}}
-            <div class="average-item">
-                <span class="item-label">Average credit:</span>
-                <span class="credit">+${{.AverageCredit.StringFixed 2}}</span>
-            </div>
-            {{end}}
-            {{if not .AverageDebit.IsZero}}
-            <div class="average-item">
-                <span class="item-label">Average debit:</span>
-                <span class="debit">-${{.AverageDebit.StringFixed 2}}</span>
-            </div>
-            {{end}}
-            {{if .AverageCredit.IsZero}} {{if .AverageDebit.IsZero}}
-            <div class="average-item">
-                <span class="item-label">No credit or debit transactions this month.</span>
-            </div>
-            {{end}}{{end}}
-        </div>
-        {{else}}
-        <div class="no-transactions">
-            🌱 Looks like there were no transactions for your account in the processed file this month.
-        </div>
-        {{end}}
-        
-        <div class="footer">
-            <p>Thank you for being a Storicard member!</p>
-            <p>Have questions? Visit our <a href="https://www.storicard.com/faq">FAQ</a> or <a href="https://www.storicard.com/contact">contact support</a>.</p>
-            <p>This is an automated message. Please do not reply directly to this email.</p>
-            <p>&copy; {{ .CurrentYear }} Storicard. All rights reserved.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// templatePatterns are parsed in order, so layouts and partials are available to pages
+var templatePatterns = []string{"layouts/*.html", "partials/*.html", "*.html"}
+
+// entryTemplate is the template executed to render a summary email
+const entryTemplate = "base"
+
+// RenderOptions selects the language and optional sections of the summary email
+type RenderOptions struct {
+	Locale i18n.Locale
+	// Statistics renders the amount distribution and largest transactions
+	Statistics bool
+}
 
 type templateData struct {
 	*domain.Summary
-	SortedMonthlyTransactions []monthTransaction
-	CurrentYear               int
+	CurrentYear    int
+	Locale         i18n.Locale
+	ShowStatistics bool
+	// ChartSrc is the monthly activity chart: a cid: reference when sending, a data: URI
+	// when previewing. Empty when there is nothing to chart
+	ChartSrc template.URL
 }
 
-type monthTransaction struct {
-	Month string
-	Count int
+// T translates a catalog message into the email locale
+func (d templateData) T(key string, args ...any) string {
+	return d.Locale.T(key, args...)
 }
 
-// RenderEmailTemplate renders the email template with summary data
-func RenderEmailTemplate(summary *domain.Summary) (string, error) {
-	tmpl, err := template.New("email").Parse(emailTemplate)
-	if err != nil {
-		return "", fmt.Errorf("parsing email template: %w", err)
+// HTML returns a catalog message that contains markup. Catalog entries are trusted
+func (d templateData) HTML(key string, args ...any) template.HTML {
+	return template.HTML(d.Locale.T(key, args...))
+}
+
+// MonthLabel formats a month in the email locale, e.g. "July 2024" or "julio de 2024"
+func (d templateData) MonthLabel(month domain.YearMonth) string {
+	return d.Locale.FormatMonthYear(month.Year, month.Month)
+}
+
+// PeriodLabel formats a range of months in the email locale, e.g. "May 2024 – June 2024"
+func (d templateData) PeriodLabel(period domain.Period) string {
+	if period.From == period.To {
+		return d.MonthLabel(period.From)
 	}
+	return d.MonthLabel(period.From) + " – " + d.MonthLabel(period.To)
+}
 
-	var sortedMonthly []monthTransaction
-	for month, count := range summary.MonthlyTransactions {
-		sortedMonthly = append(sortedMonthly, monthTransaction{
-			Month: month,
-			Count: count,
-		})
+// Delta formats a change as a signed amount with its percentage, e.g. "+$12.50 (+8.3%)"
+func (d templateData) Delta(change domain.Change) string {
+	sign := ""
+	if change.Delta.IsPositive() {
+		sign = "+"
+	}
+	text := sign + d.Locale.FormatCurrency(change.Delta)
+	if change.Percent != nil {
+		text += " (" + sign + d.Locale.FormatNumber(*change.Percent, 1) + "%)"
 	}
+	return text
+}
+
+// Date formats a date in the email locale, e.g. "July 15, 2024" or "15 de julio de 2024"
+func (d templateData) Date(t time.Time) string {
+	return d.Locale.FormatDate(t)
+}
+
+// Money formats an amount as currency in the email locale
+func (d templateData) Money(amount decimal.Decimal) string {
+	return d.Locale.FormatCurrency(amount)
+}
+
+// TemplateStore parses the email templates once and caches them. Templates come from the
+// embedded defaults, overridden file by file by those found in an optional directory
+type TemplateStore struct {
+	directory string
+	logger    *slog.Logger
+
+	mu       sync.RWMutex
+	template *template.Template
+	// version counts reloads, failed ones included; reloadErr is the error of the last one
+	version   uint64
+	reloadErr error
+}
 
-	sort.Slice(sortedMonthly, func(i, j int) bool {
-		return sortedMonthly[i].Month < sortedMonthly[j].Month
+var (
+	defaultStore     *TemplateStore
+	defaultStoreErr  error
+	defaultStoreOnce sync.Once
+)
+
+// NewTemplateStore loads the templates. An empty directory uses only the embedded templates
+func NewTemplateStore(directory string, logger *slog.Logger) (*TemplateStore, error) {
+	store := &TemplateStore{
+		directory: directory,
+		logger:    logger,
+	}
+
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func defaultTemplates() (*TemplateStore, error) {
+	defaultStoreOnce.Do(func() {
+		defaultStore, defaultStoreErr = NewTemplateStore("", slog.Default())
 	})
+	return defaultStore, defaultStoreErr
+}
+
+// Reload parses the templates again. On failure the previously loaded templates are kept
+func (s *TemplateStore) Reload() error {
+	tmpl, err := s.parse()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	s.reloadErr = err
+	if err != nil {
+		return err
+	}
+	s.template = tmpl
+	return nil
+}
+
+// Version returns a number that changes whenever the templates are reloaded, and the
+// error of the last reload, nil when the loaded templates are current
+func (s *TemplateStore) Version() (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version, s.reloadErr
+}
+
+func (s *TemplateStore) parse() (*template.Template, error) {
+	tmpl := template.New("email")
+
+	if err := parseTemplates(tmpl, embeddedTemplates, "templates"); err != nil {
+		return nil, fmt.Errorf("parsing embedded email templates: %w", err)
+	}
+
+	if s.directory != "" {
+		if err := parseTemplates(tmpl, os.DirFS(s.directory), "."); err != nil {
+			return nil, fmt.Errorf("parsing email templates from %s: %w", s.directory, err)
+		}
+	}
+
+	if tmpl.Lookup(entryTemplate) == nil {
+		return nil, fmt.Errorf("email templates do not define %q", entryTemplate)
+	}
+	return tmpl, nil
+}
+
+func parseTemplates(tmpl *template.Template, fsys fs.FS, root string) error {
+	sub, err := fs.Sub(fsys, root)
+	if err != nil {
+		return err
+	}
+
+	for _, pattern := range templatePatterns {
+		matches, err := fs.Glob(sub, pattern)
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		if _, err := tmpl.ParseFS(sub, pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Render renders the summary email, embedding the chart as a data: URI so the result
+// is self-contained
+func (s *TemplateStore) Render(summary *domain.Summary, options RenderOptions) (string, error) {
+	png, err := chart.MonthlyActivity(summary)
+	if err != nil {
+		return "", err
+	}
+
+	var chartSrc template.URL
+	if png != nil {
+		chartSrc = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+	}
+	return s.render(summary, options, chartSrc)
+}
+
+func (s *TemplateStore) render(summary *domain.Summary, options RenderOptions, chartSrc template.URL) (string, error) {
+	s.mu.RLock()
+	tmpl := s.template
+	s.mu.RUnlock()
 
 	data := templateData{
-		Summary:                   summary,
-		SortedMonthlyTransactions: sortedMonthly,
-		CurrentYear:               time.Now().Year(),
+		Summary:        summary,
+		CurrentYear:    time.Now().Year(),
+		Locale:         options.Locale,
+		ShowStatistics: options.Statistics,
+		ChartSrc:       chartSrc,
 	}
 
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
+	if err := tmpl.ExecuteTemplate(&buf, entryTemplate, data); err != nil {
 		return "", fmt.Errorf("executing email template: %w", err)
 	}
 
 	return buf.String(), nil
 }
+
+// Validate renders every fixture summary in every locale, with all optional sections,
+// and reports the ones that fail
+func (s *TemplateStore) Validate() error {
+	var errs []error
+	for _, fixture := range Fixtures() {
+		for _, locale := range i18n.Supported() {
+			if _, err := s.Render(fixture.Summary, RenderOptions{Locale: locale, Statistics: true}); err != nil {
+				errs = append(errs, fmt.Errorf("fixture %s (%s): %w", fixture.Name, locale, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Watch reloads the templates whenever a file in the template directory changes,
+// until ctx is cancelled. It is a no-op when only embedded templates are used
+func (s *TemplateStore) Watch(ctx context.Context) error {
+	if s.directory == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating template watcher: %w", err)
+	}
+
+	for _, dir := range []string{".", "layouts", "partials"} {
+		path := filepath.Join(s.directory, dir)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			if err := watcher.Add(path); err != nil {
+				watcher.Close()
+				return fmt.Errorf("watching template directory %s: %w", path, err)
+			}
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 {
+					continue
+				}
+				if err := s.Reload(); err != nil {
+					s.logger.Error("failed to reload email templates, keeping previous version", "error", err)
+					continue
+				}
+				s.logger.Info("email templates reloaded", "file", event.Name)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				s.logger.Error("template watcher error", "error", err)
+			}
+		}
+	}()
+
+	s.logger.Info("watching email templates for changes", "directory", s.directory)
+	return nil
+}
+
+// RenderEmailTemplate renders the email template with summary data using the embedded
+// templates and the default locale
+func RenderEmailTemplate(summary *domain.Summary) (string, error) {
+	store, err := defaultTemplates()
+	if err != nil {
+		return "", err
+	}
+	return store.Render(summary, RenderOptions{Locale: i18n.DefaultLocale})
+}
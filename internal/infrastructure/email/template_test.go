@@ -0,0 +1,182 @@
+package email
+
+import (
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
+)
+
+func TestTemplateStoreEmbedded(t *testing.T) {
+	store, err := NewTemplateStore("", discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := store.Validate(); err != nil {
+		t.Errorf("embedded templates failed validation: %v", err)
+	}
+}
+
+func TestTemplateStoreOverrideAndReload(t *testing.T) {
+	dir := t.TempDir()
+	partials := filepath.Join(dir, "partials")
+	if err := os.MkdirAll(partials, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	footer := filepath.Join(partials, "footer.html")
+	if err := os.WriteFile(footer, []byte(`{{define "footer"}}<p>custom footer {{.CurrentYear}}</p>{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewTemplateStore(dir, discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary := Fixtures()[1].Summary
+	html, err := store.Render(summary, RenderOptions{Locale: i18n.English})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(html, "custom footer") {
+		t.Error("rendered email does not use the overriding footer partial")
+	}
+	if !strings.Contains(html, "Monthly Transactions Overview") {
+		t.Error("rendered email lost the embedded summary content")
+	}
+
+	// A broken template fails to reload and keeps the previous version
+	if err := os.WriteFile(footer, []byte(`{{define "footer"}}{{.Missing`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err == nil {
+		t.Error("expected reload error for a broken template")
+	}
+	if html, err := store.Render(summary, RenderOptions{Locale: i18n.English}); err != nil || !strings.Contains(html, "custom footer") {
+		t.Errorf("store should keep serving the previous templates, got err=%v", err)
+	}
+}
+
+func TestTemplateStoreLocalized(t *testing.T) {
+	store, err := NewTemplateStore("", discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary := Fixtures()[3].Summary // multi-year
+	tests := []struct {
+		locale i18n.Locale
+		want   []string
+	}{
+		{i18n.English, []string{`lang="en"`, "Transactions in July 2024:", "Your Current Balance", "Closing balance", "-$10.46"}},
+		{i18n.Spanish, []string{`lang="es"`, "Transacciones en julio de 2024:", "Tu saldo actual", "$2.450,80", "Movimiento neto de este archivo", "$1.089,35"}},
+		{i18n.Portuguese, []string{`lang="pt"`, "Transações em julho de 2024:", "Seu saldo atual"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.locale), func(t *testing.T) {
+			html, err := store.Render(summary, RenderOptions{Locale: tt.locale})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("rendered email does not contain %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateStoreMonthOrder(t *testing.T) {
+	store, err := NewTemplateStore("", discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	html, err := store.Render(Fixtures()[3].Summary, RenderOptions{Locale: i18n.English}) // multi-year
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ordered := []string{"November 2023", "December 2023", "January 2024", "July 2024", "August 2024"}
+	last := -1
+	for _, month := range ordered {
+		index := strings.Index(html, "Transactions in "+month+":")
+		if index < 0 {
+			t.Fatalf("rendered email does not list %s", month)
+		}
+		if index < last {
+			t.Errorf("%s is listed out of chronological order", month)
+		}
+		last = index
+	}
+}
+
+func TestTemplateStoreStatistics(t *testing.T) {
+	store, err := NewTemplateStore("", discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary := Fixtures()[3].Summary // multi-year
+	html, err := store.Render(summary, RenderOptions{Locale: i18n.English})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(html, "Transaction Statistics") {
+		t.Error("statistics section rendered although it was not requested")
+	}
+
+	html, err = store.Render(summary, RenderOptions{Locale: i18n.English, Statistics: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"Transaction Statistics", "Largest transactions", "+$1,200.00", "January 5, 2024"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("rendered email does not contain %q", want)
+		}
+	}
+}
+
+func TestTemplateStoreComparison(t *testing.T) {
+	store, err := NewTemplateStore("", discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rendered, err := store.Render(Fixtures()[1].Summary, RenderOptions{Locale: i18n.English}) // credits-only, with history
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := html.UnescapeString(rendered)
+	for _, want := range []string{"Compared with May 2024 – June 2024", "+$45.75 (+30.5%)", "-$42.80"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("rendered email does not contain %q", want)
+		}
+	}
+}
+
+func TestTemplateStoreBalanceWithoutAccount(t *testing.T) {
+	store, err := NewTemplateStore("", discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rendered, err := store.Render(Fixtures()[2].Summary, RenderOptions{Locale: i18n.English}) // debits-only
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := html.UnescapeString(rendered)
+	if strings.Contains(text, "Your Current Balance") {
+		t.Error("file movement presented as the account balance")
+	}
+	if !strings.Contains(text, "This file's net movement") {
+		t.Error("rendered email does not label the file's net movement")
+	}
+}
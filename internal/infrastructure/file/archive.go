@@ -0,0 +1,181 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// DefaultMaxDecompressedSize bounds the decompressed size of a file or archive
+	DefaultMaxDecompressedSize = 256 << 20
+	// DefaultMaxArchiveMembers bounds the number of files processed from an archive
+	DefaultMaxArchiveMembers = 100
+)
+
+// ErrSizeLimitExceeded is returned when decompressed input exceeds the configured limits
+var ErrSizeLimitExceeded = errors.New("decompressed size limit exceeded")
+
+// compression is a stream compression format, recognized by extension or magic number
+type compression struct {
+	extension string
+	magic     []byte
+	open      func(r io.Reader, maxSize int64) (io.ReadCloser, error)
+}
+
+var compressions = []compression{
+	{
+		extension: ".gz",
+		magic:     []byte{0x1f, 0x8b},
+		open: func(r io.Reader, _ int64) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		extension: ".zst",
+		magic:     []byte{0x28, 0xb5, 0x2f, 0xfd},
+		open: func(r io.Reader, maxSize int64) (io.ReadCloser, error) {
+			decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxSize)))
+			if err != nil {
+				return nil, err
+			}
+			return decoder.IOReadCloser(), nil
+		},
+	},
+}
+
+// compressionFor returns the compression of a stream by its name or first bytes, nil when uncompressed
+func compressionFor(name string, head []byte) *compression {
+	for i, c := range compressions {
+		if strings.HasSuffix(strings.ToLower(name), c.extension) || bytes.HasPrefix(head, c.magic) {
+			return &compressions[i]
+		}
+	}
+	return nil
+}
+
+// trimCompression drops the compression extension of a name, e.g. "july.csv.gz" becomes "july.csv"
+func trimCompression(name string) string {
+	for _, c := range compressions {
+		if strings.HasSuffix(strings.ToLower(name), c.extension) {
+			return name[:len(name)-len(c.extension)]
+		}
+	}
+	return name
+}
+
+// isArchive reports whether a file is a ZIP archive whose members are processed separately.
+// Only the extension counts, since XLSX workbooks are ZIP archives too
+func isArchive(name string) bool {
+	return strings.ToLower(path.Ext(name)) == ".zip"
+}
+
+// sizeBudget is the decompressed size left to read, shared by the members of an archive
+type sizeBudget struct {
+	remaining int64
+	// plain charges streams that are not compressed too, as archive members are
+	// decompressed from the archive. Compressed streams are charged what they expand to
+	plain bool
+}
+
+// limitedReader reads from r until the budget is spent, then fails instead of truncating
+type limitedReader struct {
+	r      io.Reader
+	budget *sizeBudget
+	source string
+	err    error
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	if l.budget.remaining <= 0 {
+		// Probe for one more byte, so input of exactly the limit is accepted
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			l.err = fmt.Errorf("%w: %s", ErrSizeLimitExceeded, l.source)
+			return 0, l.err
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > l.budget.remaining {
+		p = p[:l.budget.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.budget.remaining -= int64(n)
+	return n, err
+}
+
+// processArchive processes each supported member of a ZIP archive as its own unit. Exceeding
+// the size or member limits fails the whole archive
+func (p *fileProcessor) processArchive(ctx context.Context, filePath string) ([]services.FileUnit, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: opening archive %s: %v", domain.ErrInvalidFileFormat, filePath, err)
+	}
+	defer archive.Close()
+
+	budget := &sizeBudget{remaining: p.config.maxDecompressedSize(), plain: true}
+	var units []services.FileUnit
+	for _, member := range archive.File {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		name := member.Name
+		base := path.Base(name)
+		if member.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+		if isArchive(name) || !p.supported(name) {
+			p.logger.Info("skipping unsupported archive member", "archive", filePath, "member", name)
+			continue
+		}
+
+		if len(units) == p.config.maxArchiveMembers() {
+			return nil, fmt.Errorf("%w: %s holds more than %d files", ErrSizeLimitExceeded, filePath, p.config.maxArchiveMembers())
+		}
+		// The declared size may lie; the budget is enforced while reading as well
+		if member.UncompressedSize64 > uint64(budget.remaining) {
+			return nil, fmt.Errorf("%w: %s:%s", ErrSizeLimitExceeded, filePath, name)
+		}
+
+		unit := services.FileUnit{Name: filePath + ":" + name}
+		unit.Transactions, unit.Err = p.processMember(ctx, member, unit.Name, budget)
+		if errors.Is(unit.Err, ErrSizeLimitExceeded) {
+			return nil, unit.Err
+		}
+		if unit.Err != nil {
+			p.logger.Warn("failed to process archive member", "archive", filePath, "member", name, "error", unit.Err)
+		}
+		units = append(units, unit)
+	}
+
+	if len(units) == 0 {
+		return nil, fmt.Errorf("%w: archive %s holds no supported files", domain.ErrInvalidFileFormat, filePath)
+	}
+	return units, nil
+}
+
+func (p *fileProcessor) processMember(ctx context.Context, member *zip.File, unitName string, budget *sizeBudget) ([]domain.Transaction, error) {
+	reader, err := member.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: opening %s: %v", domain.ErrInvalidFileFormat, unitName, err)
+	}
+	defer reader.Close()
+
+	return p.parse(ctx, reader, unitName, member.Name, budget)
+}
@@ -0,0 +1,59 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/iso20022"
+)
+
+// camtParser reads the booked entries of ISO 20022 camt.053 statements
+type camtParser struct {
+	logger *slog.Logger
+}
+
+func (p *camtParser) Name() string { return "camt.053" }
+
+func (p *camtParser) Extensions() []string { return []string{".xml"} }
+
+// Sniff recognizes the camt.053 namespace or its root message element
+func (p *camtParser) Sniff(head []byte) bool {
+	return bytes.Contains(head, []byte("camt.053")) || bytes.Contains(head, []byte("<BkToCstmrStmt"))
+}
+
+// Parse reads every booked entry, skipping pending entries and entries that are not valid transactions
+func (p *camtParser) Parse(ctx context.Context, r io.Reader, source string) ([]domain.Transaction, error) {
+	document, err := iso20022.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []domain.Transaction
+	for _, statement := range document.Message.Statements {
+		for index, entry := range statement.Entries {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			if !entry.Booked() {
+				p.logger.Debug("skipping entry that is not booked", "statement", statement.ID, "index", index, "file", source)
+				continue
+			}
+
+			transaction, err := entry.Transaction()
+			if err != nil {
+				p.logger.Warn("skipping invalid entry", "statement", statement.ID, "index", index, "error", err, "file", source)
+				continue
+			}
+			if transaction.Currency == "" {
+				transaction.Currency = statement.Account.Currency
+			}
+			transactions = append(transactions, *transaction)
+		}
+	}
+
+	return transactions, nil
+}
@@ -1,56 +1,52 @@
 package file
 
 import (
+	"bytes"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
-	"os"
 	"strings"
-	"time"
 
 	"github.com/NahuelDT/stori-challenge/internal/domain"
-	"github.com/NahuelDT/stori-challenge/internal/services"
-	"github.com/fsnotify/fsnotify"
 )
 
-type csvFileProcessor struct {
+// csvHeader is the expected header of transaction CSV files
+var csvHeader = []string{"Id", "Date", "Transaction"}
+
+type csvParser struct {
 	logger *slog.Logger
 }
 
-// NewCSVFileProcessor creates a new CSV file processor
-func NewCSVFileProcessor(logger *slog.Logger) services.FileProcessor {
-	return &csvFileProcessor{
-		logger: logger,
-	}
-}
+func (p *csvParser) Name() string { return "csv" }
 
-// ProcessFile processes a CSV file and returns transactions
-func (p *csvFileProcessor) ProcessFile(ctx context.Context, filePath string) ([]domain.Transaction, error) {
-	p.logger.Debug("opening file for processing", "file", filePath)
+func (p *csvParser) Extensions() []string { return []string{".csv"} }
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("opening file %s: %w", filePath, err)
-	}
-	defer file.Close()
+// Sniff recognizes the Id,Date,Transaction header
+func (p *csvParser) Sniff(head []byte) bool {
+	line, _, _ := bytes.Cut(trimHead(head), []byte("\n"))
+	fields := strings.Split(strings.TrimSpace(string(line)), ",")
+	return validateHeader(fields, csvHeader)
+}
 
-	reader := csv.NewReader(file)
+// Parse reads a CSV file with an Id,Date,Transaction header
+func (p *csvParser) Parse(ctx context.Context, r io.Reader, source string) ([]domain.Transaction, error) {
+	reader := csv.NewReader(r)
 	reader.FieldsPerRecord = 3 // ID, Date, Transaction
 
 	// Read header
 	header, err := reader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("reading header from %s: %w", filePath, err)
+		return nil, fmt.Errorf("reading header from %s: %w", source, err)
 	}
 
 	p.logger.Debug("CSV header read", "header", header)
 
 	// Validate header
-	expectedHeaders := []string{"Id", "Date", "Transaction"}
-	if !validateHeader(header, expectedHeaders) {
-		return nil, fmt.Errorf("invalid CSV header in %s, expected %v, got %v", filePath, expectedHeaders, header)
+	if !validateHeader(header, csvHeader) {
+		return nil, fmt.Errorf("invalid CSV header in %s, expected %v, got %v", source, csvHeader, header)
 	}
 
 	var transactions []domain.Transaction
@@ -68,20 +64,25 @@ func (p *csvFileProcessor) ProcessFile(ctx context.Context, filePath string) ([]
 			break
 		}
 		if err != nil {
-			p.logger.Warn("skipping invalid line", "line", lineNumber, "error", err, "file", filePath)
+			// Malformed lines are skipped; failures of the input itself end the file
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("reading %s: %w", source, err)
+			}
+			p.logger.Warn("skipping invalid line", "line", lineNumber, "error", err, "file", source)
 			lineNumber++
 			continue
 		}
 
 		if len(record) != 3 {
-			p.logger.Warn("skipping line with incorrect field count", "line", lineNumber, "fields", len(record), "file", filePath)
+			p.logger.Warn("skipping line with incorrect field count", "line", lineNumber, "fields", len(record), "file", source)
 			lineNumber++
 			continue
 		}
 
 		transaction, err := domain.NewTransaction(record[0], record[1], record[2])
 		if err != nil {
-			p.logger.Warn("skipping invalid transaction", "line", lineNumber, "error", err, "record", record, "file", filePath)
+			p.logger.Warn("skipping invalid transaction", "line", lineNumber, "error", err, "record", record, "file", source)
 			lineNumber++
 			continue
 		}
@@ -90,76 +91,10 @@ func (p *csvFileProcessor) ProcessFile(ctx context.Context, filePath string) ([]
 		lineNumber++
 	}
 
-	p.logger.Info("file processing completed", "file", filePath, "transactions", len(transactions), "lines_processed", lineNumber-1)
+	p.logger.Debug("CSV parsed", "file", source, "lines_processed", lineNumber-1)
 	return transactions, nil
 }
 
-// WatchDirectory watches a directory for new CSV files
-func (p *csvFileProcessor) WatchDirectory(ctx context.Context, dirPath string) (<-chan string, error) {
-	p.logger.Info("setting up directory watcher", "directory", dirPath)
-
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil, fmt.Errorf("creating file watcher: %w", err)
-	}
-
-	err = watcher.Add(dirPath)
-	if err != nil {
-		watcher.Close()
-		return nil, fmt.Errorf("adding directory to watcher %s: %w", dirPath, err)
-	}
-
-	fileChan := make(chan string, 10)
-
-	go func() {
-		defer watcher.Close()
-		defer close(fileChan)
-
-		for {
-			select {
-			case <-ctx.Done():
-				p.logger.Info("stopping directory watcher due to context cancellation")
-				return
-			case event, ok := <-watcher.Events:
-				if !ok {
-					p.logger.Warn("watcher events channel closed")
-					return
-				}
-
-				p.logger.Debug("file system event", "event", event.String())
-
-				if (event.Op&fsnotify.Create == fsnotify.Create ||
-					event.Op&fsnotify.Write == fsnotify.Write ||
-					event.Op&fsnotify.Chmod == fsnotify.Chmod) &&
-					strings.HasSuffix(strings.ToLower(event.Name), ".csv") {
-
-					// Wait a bit to ensure file is fully written
-					time.Sleep(100 * time.Millisecond)
-
-					// Check if readable
-					if _, err := os.Stat(event.Name); err == nil {
-						p.logger.Info("new CSV file detected", "file", event.Name)
-						select {
-						case fileChan <- event.Name:
-						case <-ctx.Done():
-							return
-						}
-					}
-				}
-			case err, ok := <-watcher.Errors:
-				if !ok {
-					p.logger.Warn("watcher errors channel closed")
-					return
-				}
-				p.logger.Error("file watcher error", "error", err)
-			}
-		}
-	}()
-
-	p.logger.Info("directory watcher started successfully", "directory", dirPath)
-	return fileChan, nil
-}
-
 func validateHeader(header, expected []string) bool {
 	if len(header) != len(expected) {
 		return false
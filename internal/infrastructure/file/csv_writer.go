@@ -0,0 +1,37 @@
+package file
+
+import (
+	"io"
+	"sort"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+// WriteTransactionsCSV writes transactions in the input CSV format (Id,Date,Transaction),
+// ordered by date with ISO dates and signed amounts
+func WriteTransactionsCSV(w io.Writer, transactions []domain.Transaction) error {
+	sorted := make([]domain.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	writer, err := NewTransactionWriter(FormatCSV, w)
+	if err != nil {
+		return err
+	}
+	for _, transaction := range sorted {
+		if err := writer.Write(transaction); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// FormatSignedAmount formats a transaction amount with its sign (e.g., "+60.50", "-10.30")
+func FormatSignedAmount(transaction domain.Transaction) string {
+	if transaction.IsDebit() {
+		return "-" + transaction.Amount.StringFixed(2)
+	}
+	return "+" + transaction.Amount.StringFixed(2)
+}
@@ -0,0 +1,163 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+// maxJSONLine bounds the length of a single NDJSON record
+const maxJSONLine = 1 << 20
+
+// jsonRecord is a transaction in JSON input. id and amount may be strings or numbers;
+// string amounts are signed like in CSV files, while numeric amounts are credits unless negative
+type jsonRecord struct {
+	ID     json.RawMessage `json:"id"`
+	Date   string          `json:"date"`
+	Amount json.RawMessage `json:"amount"`
+}
+
+func (r jsonRecord) transaction() (*domain.Transaction, error) {
+	id, err := jsonScalar(r.ID)
+	if err != nil {
+		return nil, domain.ErrInvalidTransactionID
+	}
+
+	amount, err := jsonScalar(r.Amount)
+	if err != nil {
+		return nil, domain.ErrInvalidAmount
+	}
+	if len(r.Amount) > 0 && r.Amount[0] != '"' && !strings.HasPrefix(amount, "-") {
+		amount = "+" + amount
+	}
+
+	return domain.NewTransaction(id, r.Date, amount)
+}
+
+// jsonScalar returns a JSON string or number as text
+func jsonScalar(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
+
+// jsonParser reads a JSON array of transaction objects
+type jsonParser struct {
+	logger *slog.Logger
+}
+
+func (p *jsonParser) Name() string { return "json" }
+
+func (p *jsonParser) Extensions() []string { return []string{".json"} }
+
+func (p *jsonParser) Sniff(head []byte) bool {
+	return bytes.HasPrefix(trimHead(head), []byte("["))
+}
+
+// Parse streams the array, skipping elements that are not valid transactions
+func (p *jsonParser) Parse(ctx context.Context, r io.Reader, source string) ([]domain.Transaction, error) {
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("%w: %s does not hold a JSON array", domain.ErrInvalidFileFormat, source)
+	}
+
+	var transactions []domain.Transaction
+	for index := 0; decoder.More(); index++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return nil, fmt.Errorf("reading element %d of %s: %w", index, source, err)
+		}
+
+		var record jsonRecord
+		if err := json.Unmarshal(element, &record); err != nil {
+			p.logger.Warn("skipping invalid element", "index", index, "error", err, "file", source)
+			continue
+		}
+		transaction, err := record.transaction()
+		if err != nil {
+			p.logger.Warn("skipping invalid transaction", "index", index, "error", err, "record", string(element), "file", source)
+			continue
+		}
+		transactions = append(transactions, *transaction)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("reading end of %s: %w", source, err)
+	}
+
+	return transactions, nil
+}
+
+// ndjsonParser reads newline-delimited JSON, one transaction object per line
+type ndjsonParser struct {
+	logger *slog.Logger
+}
+
+func (p *ndjsonParser) Name() string { return "ndjson" }
+
+func (p *ndjsonParser) Extensions() []string { return []string{".ndjson", ".jsonl"} }
+
+func (p *ndjsonParser) Sniff(head []byte) bool {
+	return bytes.HasPrefix(trimHead(head), []byte("{"))
+}
+
+// Parse reads line by line, skipping blank lines and lines that are not valid transactions
+func (p *ndjsonParser) Parse(ctx context.Context, r io.Reader, source string) ([]domain.Transaction, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLine)
+
+	var transactions []domain.Transaction
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var record jsonRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			p.logger.Warn("skipping invalid line", "line", lineNumber, "error", err, "file", source)
+			continue
+		}
+		transaction, err := record.transaction()
+		if err != nil {
+			p.logger.Warn("skipping invalid transaction", "line", lineNumber, "error", err, "record", string(line), "file", source)
+			continue
+		}
+		transactions = append(transactions, *transaction)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,52 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MoveFile moves a file into a directory, created if needed, under its name prefixed with
+// the current time so earlier files of the same name are kept. It returns the new path
+func (p *fileProcessor) MoveFile(filePath, directory string) (string, error) {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return "", fmt.Errorf("creating directory %s: %w", directory, err)
+	}
+
+	target := filepath.Join(directory, time.Now().UTC().Format("20060102T150405.000")+"-"+filepath.Base(filePath))
+	if err := os.Rename(filePath, target); err == nil {
+		p.logger.Debug("file moved", "from", filePath, "to", target)
+		return target, nil
+	}
+
+	// Rename fails across file systems, e.g. between Docker volumes
+	if err := copyFile(filePath, target); err != nil {
+		os.Remove(target)
+		return "", fmt.Errorf("moving %s to %s: %w", filePath, directory, err)
+	}
+	if err := os.Remove(filePath); err != nil {
+		return "", fmt.Errorf("removing %s after copying it to %s: %w", filePath, directory, err)
+	}
+	p.logger.Debug("file moved", "from", filePath, "to", target)
+	return target, nil
+}
+
+func copyFile(from, to string) error {
+	source, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return err
+	}
+	return target.Close()
+}
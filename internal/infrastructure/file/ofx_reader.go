@@ -0,0 +1,169 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+// maxOFXSize bounds the size of an OFX statement read into memory
+const maxOFXSize = 32 << 20
+
+// ofxEntities decodes the character entities allowed in OFX values
+var ofxEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&nbsp;", " ", "&amp;", "&")
+
+// ofxParser reads STMTTRN records of OFX/QFX bank statements, both OFX 1.x (SGML, where
+// elements have no closing tags) and OFX 2.x (XML)
+type ofxParser struct {
+	logger *slog.Logger
+}
+
+func (p *ofxParser) Name() string { return "ofx" }
+
+func (p *ofxParser) Extensions() []string { return []string{".ofx", ".qfx"} }
+
+// Sniff recognizes the OFX 1.x header, the OFX 2.x processing instruction or the root element
+func (p *ofxParser) Sniff(head []byte) bool {
+	head = bytes.ToUpper(trimHead(head))
+	return bytes.HasPrefix(head, []byte("OFXHEADER")) ||
+		bytes.Contains(head, []byte("<?OFX")) ||
+		bytes.Contains(head, []byte("<OFX>"))
+}
+
+// Parse reads every STMTTRN record, skipping records that are not valid transactions
+func (p *ofxParser) Parse(ctx context.Context, r io.Reader, source string) ([]domain.Transaction, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxOFXSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+	if len(content) > maxOFXSize {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", domain.ErrInvalidFileFormat, source, maxOFXSize)
+	}
+
+	body := string(content)
+	start := strings.Index(strings.ToUpper(body), "<OFX>")
+	if start < 0 {
+		return nil, fmt.Errorf("%w: %s has no OFX element", domain.ErrInvalidFileFormat, source)
+	}
+
+	var (
+		transactions []domain.Transaction
+		record       map[string]string
+		index        int
+		// currency is the statement's default currency (CURDEF)
+		currency string
+	)
+	for tag, value, rest := nextOFXElement(body[start:]); tag != ""; tag, value, rest = nextOFXElement(rest) {
+		switch tag {
+		case "CURDEF":
+			currency = strings.ToUpper(value)
+		case "STMTTRN":
+			record = map[string]string{}
+		case "/STMTTRN":
+			if record == nil {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			transaction, err := ofxTransaction(record)
+			if err != nil {
+				p.logger.Warn("skipping invalid transaction", "index", index, "error", err, "fitid", record["FITID"], "file", source)
+			} else {
+				transaction.Currency = currency
+				transactions = append(transactions, *transaction)
+			}
+			record = nil
+			index++
+		default:
+			// Keep the first value of each element, so the NAME of a PAYEE aggregate
+			// does not replace the transaction's own NAME
+			if record != nil && !strings.HasPrefix(tag, "/") {
+				if _, ok := record[tag]; !ok && value != "" {
+					record[tag] = value
+				}
+			}
+		}
+	}
+
+	return transactions, nil
+}
+
+// nextOFXElement returns the next tag (upper-cased, "/NAME" for closing tags), the text
+// that follows it up to the next tag, and the remaining input. The tag is empty at the end
+func nextOFXElement(s string) (tag, value, rest string) {
+	open := strings.IndexByte(s, '<')
+	if open < 0 {
+		return "", "", ""
+	}
+	end := strings.IndexByte(s[open:], '>')
+	if end < 0 {
+		return "", "", ""
+	}
+	tag = strings.ToUpper(strings.TrimSpace(s[open+1 : open+end]))
+	rest = s[open+end+1:]
+
+	text := rest
+	if next := strings.IndexByte(rest, '<'); next >= 0 {
+		text = rest[:next]
+	}
+	return tag, ofxEntities.Replace(strings.TrimSpace(text)), rest
+}
+
+// ofxTransaction converts the elements of a STMTTRN record into a transaction
+func ofxTransaction(record map[string]string) (*domain.Transaction, error) {
+	fitID := record["FITID"]
+	if fitID == "" {
+		return nil, domain.ErrInvalidTransactionID
+	}
+
+	date, err := ofxDate(record["DTPOSTED"])
+	if err != nil {
+		return nil, err
+	}
+
+	amount := strings.ReplaceAll(record["TRNAMT"], ",", ".")
+	if amount != "" && !strings.HasPrefix(amount, "-") && !strings.HasPrefix(amount, "+") {
+		amount = "+" + amount
+	}
+
+	transaction, err := domain.NewTransaction(strconv.Itoa(domain.TransactionIDFromReference(fitID)), date, amount)
+	if err != nil {
+		return nil, err
+	}
+	transaction.Reference = fitID
+	transaction.Description = ofxDescription(record["NAME"], record["MEMO"])
+
+	return transaction, nil
+}
+
+// ofxDate returns the calendar date of an OFX datetime (YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]])
+// in the ISO format accepted by domain.NewTransaction
+func ofxDate(value string) (string, error) {
+	if len(value) < 8 {
+		return "", domain.ErrInvalidDate
+	}
+	if _, err := strconv.Atoi(value[:8]); err != nil {
+		return "", domain.ErrInvalidDate
+	}
+	return value[0:4] + "-" + value[4:6] + "-" + value[6:8], nil
+}
+
+// ofxDescription joins the payee name and the memo, dropping a memo that repeats the name
+func ofxDescription(name, memo string) string {
+	switch {
+	case name == "":
+		return memo
+	case memo == "" || memo == name:
+		return name
+	default:
+		return name + " - " + memo
+	}
+}
@@ -0,0 +1,65 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+// Parser reads transactions in one file format. Records that fail validation are
+// logged and skipped; an error means the file as a whole could not be read
+type Parser interface {
+	// Name identifies the format in logs, e.g. "csv"
+	Name() string
+	// Extensions lists the lower-case file extensions of the format, e.g. ".csv"
+	Extensions() []string
+	// Sniff reports whether the beginning of a file looks like this format
+	Sniff(head []byte) bool
+	// Parse reads every transaction from r; source names the input in logs and errors
+	Parse(ctx context.Context, r io.Reader, source string) ([]domain.Transaction, error)
+}
+
+// ReaderConfig holds the options of format parsers
+type ReaderConfig struct {
+	// XLSXSheet is the name of the workbook sheet holding transactions, the first sheet when empty
+	XLSXSheet string
+	// MaxDecompressedSize bounds the bytes read from a compressed file, an archive or a
+	// workbook, DefaultMaxDecompressedSize when zero
+	MaxDecompressedSize int64
+	// MaxArchiveMembers bounds the files processed from an archive, DefaultMaxArchiveMembers when zero
+	MaxArchiveMembers int
+}
+
+func (c ReaderConfig) maxDecompressedSize() int64 {
+	if c.MaxDecompressedSize > 0 {
+		return c.MaxDecompressedSize
+	}
+	return DefaultMaxDecompressedSize
+}
+
+func (c ReaderConfig) maxArchiveMembers() int {
+	if c.MaxArchiveMembers > 0 {
+		return c.MaxArchiveMembers
+	}
+	return DefaultMaxArchiveMembers
+}
+
+// DefaultParsers returns the parsers of every supported format
+func DefaultParsers(config ReaderConfig, logger *slog.Logger) []Parser {
+	return []Parser{
+		&csvParser{logger: logger},
+		&jsonParser{logger: logger},
+		&ndjsonParser{logger: logger},
+		&ofxParser{logger: logger},
+		&camtParser{logger: logger},
+		&xlsxParser{sheet: config.XLSXSheet, maxSize: config.maxDecompressedSize(), logger: logger},
+	}
+}
+
+// trimHead drops a UTF-8 byte order mark and leading whitespace before sniffing
+func trimHead(head []byte) []byte {
+	return bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n")
+}
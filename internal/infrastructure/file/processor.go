@@ -0,0 +1,241 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+	"github.com/fsnotify/fsnotify"
+)
+
+// sniffLength is the number of leading bytes inspected to detect a file format
+const sniffLength = 512
+
+type fileProcessor struct {
+	config  ReaderConfig
+	parsers []Parser
+	logger  *slog.Logger
+}
+
+// NewFileProcessor creates a file processor that reads every format of DefaultParsers
+func NewFileProcessor(config ReaderConfig, logger *slog.Logger) services.FileProcessor {
+	return NewFileProcessorWithParsers(config, DefaultParsers(config, logger), logger)
+}
+
+// NewFileProcessorWithParsers creates a file processor reading the formats of the given parsers
+func NewFileProcessorWithParsers(config ReaderConfig, parsers []Parser, logger *slog.Logger) services.FileProcessor {
+	return &fileProcessor{
+		config:  config,
+		parsers: parsers,
+		logger:  logger,
+	}
+}
+
+// ProcessFile returns the transactions of a file. For archives, these are the transactions
+// of every member, and the first member that cannot be read fails the whole file
+func (p *fileProcessor) ProcessFile(ctx context.Context, filePath string) ([]domain.Transaction, error) {
+	units, err := p.ProcessUnits(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []domain.Transaction
+	for _, unit := range units {
+		if unit.Err != nil {
+			return nil, unit.Err
+		}
+		transactions = append(transactions, unit.Transactions...)
+	}
+	return transactions, nil
+}
+
+// ProcessUnits reads a file as independent units: each member of a ZIP archive, or the
+// file itself. gzip and zstd compressed files are decompressed transparently
+func (p *fileProcessor) ProcessUnits(ctx context.Context, filePath string) ([]services.FileUnit, error) {
+	if isArchive(filePath) {
+		return p.processArchive(ctx, filePath)
+	}
+
+	p.logger.Debug("opening file for processing", "file", filePath)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	budget := &sizeBudget{remaining: p.config.maxDecompressedSize()}
+	transactions, err := p.parse(ctx, file, filePath, filePath, budget)
+	if err != nil {
+		return nil, err
+	}
+	return []services.FileUnit{{Name: filePath, Transactions: transactions}}, nil
+}
+
+// parse decompresses a stream if needed and parses it with the parser of its format.
+// source names the stream in logs and errors, name gives the extensions of the format
+func (p *fileProcessor) parse(ctx context.Context, r io.Reader, source, name string, budget *sizeBudget) ([]domain.Transaction, error) {
+	reader := bufio.NewReaderSize(r, sniffLength)
+	head, err := peek(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+
+	if c := compressionFor(name, head); c != nil {
+		decompressed, err := c.open(reader, budget.remaining)
+		if err != nil {
+			return nil, fmt.Errorf("%w: decompressing %s: %v", domain.ErrInvalidFileFormat, source, err)
+		}
+		defer decompressed.Close()
+
+		reader = bufio.NewReaderSize(&limitedReader{r: decompressed, budget: budget, source: source}, sniffLength)
+		if head, err = peek(reader); err != nil {
+			return nil, fmt.Errorf("decompressing %s: %w", source, err)
+		}
+		name = trimCompression(name)
+	} else if budget.plain {
+		reader = bufio.NewReaderSize(&limitedReader{r: reader, budget: budget, source: source}, sniffLength)
+	}
+
+	parser, err := p.parserFor(name, head)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := parser.Parse(ctx, reader, source)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("file processing completed", "file", source, "format", parser.Name(), "transactions", len(transactions))
+	return transactions, nil
+}
+
+// peek returns the first bytes of a stream for format detection
+func peek(reader *bufio.Reader) ([]byte, error) {
+	head, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	return head, nil
+}
+
+// parserFor picks the parser for a file: by extension, unless the content contradicts it
+// (e.g. NDJSON saved as .json), then by content alone
+func (p *fileProcessor) parserFor(filePath string, head []byte) (Parser, error) {
+	extension := strings.ToLower(filepath.Ext(filePath))
+
+	var byExtension []Parser
+	for _, parser := range p.parsers {
+		if slices.Contains(parser.Extensions(), extension) {
+			byExtension = append(byExtension, parser)
+		}
+	}
+
+	for _, parser := range byExtension {
+		if parser.Sniff(head) {
+			return parser, nil
+		}
+	}
+	for _, parser := range p.parsers {
+		if parser.Sniff(head) {
+			return parser, nil
+		}
+	}
+
+	// Let the parser of the extension report what is wrong with the content
+	if len(byExtension) > 0 {
+		return byExtension[0], nil
+	}
+	return nil, fmt.Errorf("%w: unrecognized format of %s", domain.ErrInvalidFileFormat, filePath)
+}
+
+// supported reports whether a file has the extension of a known format, possibly
+// compressed, or is an archive
+func (p *fileProcessor) supported(filePath string) bool {
+	if isArchive(filePath) {
+		return true
+	}
+	extension := strings.ToLower(filepath.Ext(trimCompression(filePath)))
+	for _, parser := range p.parsers {
+		if slices.Contains(parser.Extensions(), extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchDirectory watches a directory for new files in a supported format
+func (p *fileProcessor) WatchDirectory(ctx context.Context, dirPath string) (<-chan string, error) {
+	p.logger.Info("setting up directory watcher", "directory", dirPath)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating file watcher: %w", err)
+	}
+
+	err = watcher.Add(dirPath)
+	if err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("adding directory to watcher %s: %w", dirPath, err)
+	}
+
+	fileChan := make(chan string, 10)
+
+	go func() {
+		defer watcher.Close()
+		defer close(fileChan)
+
+		for {
+			select {
+			case <-ctx.Done():
+				p.logger.Info("stopping directory watcher due to context cancellation")
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					p.logger.Warn("watcher events channel closed")
+					return
+				}
+
+				p.logger.Debug("file system event", "event", event.String())
+
+				if (event.Op&fsnotify.Create == fsnotify.Create ||
+					event.Op&fsnotify.Write == fsnotify.Write ||
+					event.Op&fsnotify.Chmod == fsnotify.Chmod) &&
+					p.supported(event.Name) {
+
+					// Wait a bit to ensure file is fully written
+					time.Sleep(100 * time.Millisecond)
+
+					// Check if readable
+					if _, err := os.Stat(event.Name); err == nil {
+						p.logger.Info("new transaction file detected", "file", event.Name)
+						select {
+						case fileChan <- event.Name:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					p.logger.Warn("watcher errors channel closed")
+					return
+				}
+				p.logger.Error("file watcher error", "error", err)
+			}
+		}
+	}()
+
+	p.logger.Info("directory watcher started successfully", "directory", dirPath)
+	return fileChan, nil
+}
@@ -0,0 +1,530 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+	"github.com/klauspost/compress/zstd"
+	"github.com/shopspring/decimal"
+)
+
+func TestProcessFileFormats(t *testing.T) {
+	files := map[string]string{
+		"transactions.csv": "Id,Date,Transaction\n0,7/15/2024,+60.5\n1,7/28/2024,-10.3\n2,8/2/2024,-20.46\n",
+		"transactions.json": `[
+			{"id": 0, "date": "7/15/2024", "amount": 60.5},
+			{"id": "1", "date": "7/28/2024", "amount": "-10.3"},
+			{"id": 2, "date": "2024-08-02", "amount": -20.46}
+		]`,
+		"transactions.ndjson": "{\"id\":0,\"date\":\"7/15/2024\",\"amount\":\"+60.5\"}\n\n" +
+			"{\"id\":1,\"date\":\"7/28/2024\",\"amount\":-10.3}\n" +
+			"not json\n" +
+			"{\"id\":2,\"date\":\"8/2/2024\",\"amount\":-20.46}\n",
+		// NDJSON saved with a .json extension is detected from its content
+		"mislabeled.json": "{\"id\":0,\"date\":\"7/15/2024\",\"amount\":60.5}\n" +
+			"{\"id\":1,\"date\":\"7/28/2024\",\"amount\":-10.3}\n" +
+			"{\"id\":2,\"date\":\"8/2/2024\",\"amount\":-20.46}\n",
+	}
+
+	dir := t.TempDir()
+	processor := NewFileProcessor(ReaderConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var want []domain.Transaction
+	for _, name := range []string{"transactions.csv", "transactions.json", "transactions.ndjson", "mislabeled.json"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := processor.ProcessFile(context.Background(), path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(got) != 3 {
+			t.Fatalf("%s: got %d transactions, want 3", name, len(got))
+		}
+		if want == nil {
+			want = got
+			continue
+		}
+		for i := range want {
+			if got[i].ID != want[i].ID || !got[i].Date.Equal(want[i].Date) ||
+				!got[i].Amount.Equal(want[i].Amount) || got[i].Type != want[i].Type {
+				t.Errorf("%s: transaction %d = %+v, want %+v", name, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestProcessFileUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewFileProcessor(ReaderConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, err := processor.ProcessFile(context.Background(), path); !errors.Is(err, domain.ErrInvalidFileFormat) {
+		t.Errorf("error = %v, want ErrInvalidFileFormat", err)
+	}
+}
+
+func TestProcessFileOFX(t *testing.T) {
+	files := map[string]string{
+		"statement.ofx": `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKTRANLIST>
+<DTSTART>20240701
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240715120000.000[-5:EST]
+<TRNAMT>60.50
+<FITID>1001
+<NAME>ACME PAYROLL
+<MEMO>July salary
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240728
+<TRNAMT>-10.30
+<FITID>2024072800A
+<NAME>Coffee &amp; Co
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>bad
+<TRNAMT>-1.00
+<FITID>3
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`,
+		"statement.qfx": `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>
+<STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20240715</DTPOSTED><TRNAMT>60.50</TRNAMT><FITID>1001</FITID><NAME>ACME PAYROLL</NAME><MEMO>July salary</MEMO></STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20240728093000</DTPOSTED><TRNAMT>-10.30</TRNAMT><FITID>2024072800A</FITID><NAME>Coffee &amp; Co</NAME></STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`,
+	}
+
+	dir := t.TempDir()
+	processor := NewFileProcessor(ReaderConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := processor.ProcessFile(context.Background(), path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("%s: got %d transactions, want 2", name, len(got))
+		}
+
+		credit, debit := got[0], got[1]
+		if credit.ID != 1001 || credit.Reference != "1001" || !credit.IsCredit() ||
+			credit.Amount.String() != "60.5" || credit.Date.Format("2006-01-02") != "2024-07-15" ||
+			credit.Description != "ACME PAYROLL - July salary" {
+			t.Errorf("%s: credit = %+v", name, credit)
+		}
+		if debit.ID != domain.TransactionIDFromReference("2024072800A") || debit.Reference != "2024072800A" || !debit.IsDebit() ||
+			debit.Amount.String() != "10.3" || debit.Date.Format("2006-01-02") != "2024-07-28" ||
+			debit.Description != "Coffee & Co" {
+			t.Errorf("%s: debit = %+v", name, debit)
+		}
+	}
+}
+
+// writeWorkbook writes an XLSX file with the given sheets, each as its sheetData XML
+func writeWorkbook(t *testing.T, path string, sheets map[string]string, sharedStrings []string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	add := func(name, content string) {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := make([]string, 0, len(sheets))
+	for name := range sheets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var book, rels strings.Builder
+	book.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, name := range names {
+		fmt.Fprintf(&book, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, name, i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+		add(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), `<worksheet><sheetData>`+sheets[name]+`</sheetData></worksheet>`)
+	}
+	book.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`</Relationships>`)
+
+	var shared strings.Builder
+	shared.WriteString(`<sst>`)
+	for _, s := range sharedStrings {
+		fmt.Fprintf(&shared, `<si><t>%s</t></si>`, s)
+	}
+	shared.WriteString(`</sst>`)
+
+	add("[Content_Types].xml", `<Types/>`)
+	add("xl/workbook.xml", book.String())
+	add("xl/_rels/workbook.xml.rels", rels.String())
+	add("xl/sharedStrings.xml", shared.String())
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessFileXLSX(t *testing.T) {
+	header := `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>`
+	transactions := header +
+		// serial date 45488 is 2024-07-15, unsigned numeric amounts are credits
+		`<row r="2"><c r="A2"><v>0</v></c><c r="B2"><v>45488</v></c><c r="C2"><v>60.5</v></c></row>` +
+		`<row r="3"><c r="A3"><v>1</v></c><c r="B3" t="s"><v>3</v></c><c r="C3"><v>-10.3</v></c></row>` +
+		`<row r="5"><c r="A5"><v>2</v></c><c r="B5" t="inlineStr"><is><t>8/2/2024</t></is></c><c r="C5" t="str"><v>-20.46</v></c></row>` +
+		`<row r="6"><c r="A6"><v>3</v></c><c r="C6"><v>1</v></c></row>` +
+		// float artifacts and exponents are rounded to cents
+		`<row r="7"><c r="A7"><v>4</v></c><c r="B7"><v>45488</v></c><c r="C7"><v>10.300000000000001</v></c></row>` +
+		`<row r="8"><c r="A8"><v>5</v></c><c r="B8"><v>45488</v></c><c r="C8"><v>-2.046E1</v></c></row>` +
+		`<row r="9"><c r="A9"><v>6</v></c><c r="B9"><v>45488</v></c><c r="C9"><v>1.5E-1</v></c></row>`
+	sharedStrings := []string{"Id", "Date", "Transaction", "2024-07-28"}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "transactions.xlsx")
+	writeWorkbook(t, path, map[string]string{"A notes": `<row r="1"><c r="A1" t="inlineStr"><is><t>notes</t></is></c></row>`, "B data": transactions}, sharedStrings)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// The first sheet holds no transactions
+	if _, err := NewFileProcessor(ReaderConfig{}, logger).ProcessFile(context.Background(), path); err == nil {
+		t.Error("expected an invalid header error for the first sheet")
+	}
+
+	got, err := NewFileProcessor(ReaderConfig{XLSXSheet: "B data"}, logger).ProcessFile(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		date   string
+		amount string
+		credit bool
+	}{
+		{"2024-07-15", "60.5", true},
+		{"2024-07-28", "10.3", false},
+		{"2024-08-02", "20.46", false},
+		{"2024-07-15", "10.3", true},
+		{"2024-07-15", "20.46", false},
+		{"2024-07-15", "0.15", true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		id := i
+		if i > 2 {
+			id++ // row 6 has no date
+		}
+		if got[i].ID != id || got[i].Date.Format("2006-01-02") != w.date || got[i].Amount.String() != w.amount || got[i].IsCredit() != w.credit {
+			t.Errorf("transaction %d = %+v, want %+v", i, got[i], w)
+		}
+	}
+}
+
+func TestProcessUnitsCompressed(t *testing.T) {
+	csvContent := "Id,Date,Transaction\n0,7/15/2024,+60.5\n1,7/28/2024,-10.3\n"
+	ndjsonContent := "{\"id\":0,\"date\":\"7/15/2024\",\"amount\":60.5}\n{\"id\":1,\"date\":\"7/28/2024\",\"amount\":-10.3}\n"
+
+	var gz bytes.Buffer
+	gzWriter := gzip.NewWriter(&gz)
+	gzWriter.Write([]byte(csvContent))
+	gzWriter.Close()
+
+	var zst bytes.Buffer
+	zstWriter, err := zstd.NewWriter(&zst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zstWriter.Write([]byte(ndjsonContent))
+	zstWriter.Close()
+
+	var bundle bytes.Buffer
+	archive := zip.NewWriter(&bundle)
+	for name, content := range map[string][]byte{
+		"july/transactions.csv": []byte(csvContent),
+		"august.ndjson.zst":     zst.Bytes(),
+		"broken.csv":            []byte("not,a,header\n"),
+		"notes.txt":             []byte("ignored"),
+		"__MACOSX/._july.csv":   []byte("ignored"),
+	} {
+		w, _ := archive.Create(name)
+		w.Write(content)
+	}
+	archive.Close()
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"transactions.csv.gz":     gz.Bytes(),
+		"transactions.ndjson.zst": zst.Bytes(),
+		// gzip content is detected without the extension
+		"renamed.csv": gz.Bytes(),
+		"bundle.zip":  bundle.Bytes(),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	processor := NewFileProcessor(ReaderConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	for _, name := range []string{"transactions.csv.gz", "transactions.ndjson.zst", "renamed.csv"} {
+		units, err := processor.ProcessUnits(ctx, filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(units) != 1 || units[0].Err != nil || len(units[0].Transactions) != 2 {
+			t.Errorf("%s: units = %+v", name, units)
+		}
+	}
+
+	units, err := processor.ProcessUnits(ctx, filepath.Join(dir, "bundle.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]services.FileUnit{}
+	for _, unit := range units {
+		results[strings.TrimPrefix(unit.Name, filepath.Join(dir, "bundle.zip")+":")] = unit
+	}
+	if len(results) != 3 {
+		t.Fatalf("got units %v, want the csv, ndjson.zst and broken members", results)
+	}
+	for _, member := range []string{"july/transactions.csv", "august.ndjson.zst"} {
+		if unit := results[member]; unit.Err != nil || len(unit.Transactions) != 2 {
+			t.Errorf("%s: %+v", member, unit)
+		}
+	}
+	if results["broken.csv"].Err == nil {
+		t.Error("broken.csv: expected an error")
+	}
+}
+
+func TestProcessUnitsSizeLimits(t *testing.T) {
+	// A small archive and gzip stream that expand far beyond the limit
+	zeros := make([]byte, 1<<20)
+
+	var gz bytes.Buffer
+	gzWriter := gzip.NewWriter(&gz)
+	gzWriter.Write([]byte("Id,Date,Transaction\n"))
+	gzWriter.Write(zeros)
+	gzWriter.Close()
+
+	var bundle bytes.Buffer
+	archive := zip.NewWriter(&bundle)
+	for i := range 3 {
+		w, _ := archive.Create(fmt.Sprintf("part%d.csv", i))
+		w.Write([]byte("Id,Date,Transaction\n0,7/15/2024,+60.5\n"))
+	}
+	archive.Close()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "bomb.csv.gz"), gz.Bytes(), 0o644)
+	os.WriteFile(filepath.Join(dir, "bundle.zip"), bundle.Bytes(), 0o644)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	small := NewFileProcessor(ReaderConfig{MaxDecompressedSize: 64 << 10}, logger)
+	if _, err := small.ProcessUnits(ctx, filepath.Join(dir, "bomb.csv.gz")); !errors.Is(err, ErrSizeLimitExceeded) {
+		t.Errorf("gzip: error = %v, want ErrSizeLimitExceeded", err)
+	}
+
+	few := NewFileProcessor(ReaderConfig{MaxArchiveMembers: 2}, logger)
+	if _, err := few.ProcessUnits(ctx, filepath.Join(dir, "bundle.zip")); !errors.Is(err, ErrSizeLimitExceeded) {
+		t.Errorf("members: error = %v, want ErrSizeLimitExceeded", err)
+	}
+
+	tiny := NewFileProcessor(ReaderConfig{MaxDecompressedSize: 64}, logger)
+	if _, err := tiny.ProcessUnits(ctx, filepath.Join(dir, "bundle.zip")); !errors.Is(err, ErrSizeLimitExceeded) {
+		t.Errorf("archive size: error = %v, want ErrSizeLimitExceeded", err)
+	}
+}
+
+func TestProcessUnitsCompressedMemberBudget(t *testing.T) {
+	// Only the decompressed bytes of a compressed member count against the limit
+	var content strings.Builder
+	content.WriteString("Id,Date,Transaction\n")
+	for i := range 200 {
+		fmt.Fprintf(&content, "%d,7/%d/2024,+%d.%02d\n", i, i%28+1, i*7919%10000, i*31%100)
+	}
+
+	var gz bytes.Buffer
+	gzWriter := gzip.NewWriter(&gz)
+	gzWriter.Write([]byte(content.String()))
+	gzWriter.Close()
+
+	var bundle bytes.Buffer
+	archive := zip.NewWriter(&bundle)
+	w, _ := archive.Create("july.csv.gz")
+	w.Write(gz.Bytes())
+	archive.Close()
+
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	if err := os.WriteFile(path, bundle.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	size := int64(content.Len())
+
+	units, err := NewFileProcessor(ReaderConfig{MaxDecompressedSize: size}, logger).ProcessUnits(ctx, path)
+	if err != nil {
+		t.Fatalf("at the limit: %v", err)
+	}
+	if len(units) != 1 || units[0].Err != nil || len(units[0].Transactions) != 200 {
+		t.Errorf("at the limit: units = %+v", units)
+	}
+
+	if _, err := NewFileProcessor(ReaderConfig{MaxDecompressedSize: size - 1}, logger).ProcessUnits(ctx, path); !errors.Is(err, ErrSizeLimitExceeded) {
+		t.Errorf("over the limit: error = %v, want ErrSizeLimitExceeded", err)
+	}
+}
+
+func TestTransactionWriterRoundTrip(t *testing.T) {
+	transactions := []domain.Transaction{
+		{ID: 0, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("60.5"), Type: domain.Credit},
+		{ID: 1, Date: time.Date(2024, 7, 28, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("10.3"), Type: domain.Debit, Reference: "REF-1", Description: "Coffee"},
+	}
+
+	dir := t.TempDir()
+	processor := NewFileProcessor(ReaderConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, format := range ExportFormats {
+		var buf bytes.Buffer
+		writer, err := NewTransactionWriter(format, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, transaction := range transactions {
+			if err := writer.Write(transaction); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(dir, "export."+format)
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := processor.ProcessFile(context.Background(), path)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", format, err, buf.String())
+		}
+		if len(got) != len(transactions) {
+			t.Fatalf("%s: got %d transactions, want %d", format, len(got), len(transactions))
+		}
+		for i := range transactions {
+			if got[i].ID != transactions[i].ID || !got[i].Date.Equal(transactions[i].Date) ||
+				!got[i].Amount.Equal(transactions[i].Amount) || got[i].Type != transactions[i].Type {
+				t.Errorf("%s: transaction %d = %+v, want %+v", format, i, got[i], transactions[i])
+			}
+		}
+	}
+
+	var empty bytes.Buffer
+	writer, _ := NewTransactionWriter(FormatJSON, &empty)
+	writer.Close()
+	if empty.String() != "[]\n" {
+		t.Errorf("empty JSON export = %q", empty.String())
+	}
+}
+
+func TestMoveFileKeepsEarlierFiles(t *testing.T) {
+	dir := t.TempDir()
+	quarantine := filepath.Join(dir, "quarantine")
+	processor := NewFileProcessor(ReaderConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var targets []string
+	for _, content := range []string{"first", "second"} {
+		path := filepath.Join(dir, "broken.csv")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		target, err := processor.MoveFile(path, quarantine)
+		if err != nil {
+			t.Fatalf("MoveFile: %v", err)
+		}
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s still exists after the move", path)
+		}
+		targets = append(targets, target)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	if targets[0] == targets[1] {
+		t.Fatalf("both moves went to %s", targets[0])
+	}
+	for i, want := range []string{"first", "second"} {
+		if filepath.Dir(targets[i]) != quarantine || !strings.HasSuffix(targets[i], "-broken.csv") {
+			t.Errorf("target = %s, want %s/*-broken.csv", targets[i], quarantine)
+		}
+		if got, err := os.ReadFile(targets[i]); err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", targets[i], got, err, want)
+		}
+	}
+}
+
+func TestWriteTransactionsCSV(t *testing.T) {
+	transactions := []domain.Transaction{
+		{ID: 2, Date: time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("20.46"), Type: domain.Debit},
+		{ID: 0, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("60.5"), Type: domain.Credit},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTransactionsCSV(&buf, transactions); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Id,Date,Transaction\n0,2024-07-15,+60.50\n2,2024-08-02,-20.46\n"; buf.String() != want {
+		t.Errorf("CSV = %q, want %q", buf.String(), want)
+	}
+	if transactions[0].ID != 2 {
+		t.Error("WriteTransactionsCSV reordered its input")
+	}
+}
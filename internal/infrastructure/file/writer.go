@@ -0,0 +1,154 @@
+package file
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+// Export formats
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// ExportFormats lists the formats of NewTransactionWriter
+var ExportFormats = []string{FormatCSV, FormatJSON, FormatNDJSON}
+
+// TransactionWriter writes transactions one at a time, so exports do not hold them in memory
+type TransactionWriter interface {
+	Write(transaction domain.Transaction) error
+	// Close completes the output; it does not close the underlying writer
+	Close() error
+}
+
+// NewTransactionWriter creates a writer in one of ExportFormats. Every format is read back
+// by the file processor
+func NewTransactionWriter(format string, w io.Writer) (TransactionWriter, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvHeader); err != nil {
+			return nil, fmt.Errorf("writing CSV header: %w", err)
+		}
+		return &csvTransactionWriter{writer: writer}, nil
+	case FormatJSON:
+		return &jsonTransactionWriter{w: w}, nil
+	case FormatNDJSON:
+		return &ndjsonTransactionWriter{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q, expected one of %v", format, ExportFormats)
+	}
+}
+
+// ContentType returns the media type of an export format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// csvTransactionWriter writes the input CSV format (Id,Date,Transaction)
+type csvTransactionWriter struct {
+	writer *csv.Writer
+}
+
+func (t *csvTransactionWriter) Write(transaction domain.Transaction) error {
+	record := []string{
+		strconv.Itoa(transaction.ID),
+		transaction.Date.Format("2006-01-02"),
+		FormatSignedAmount(transaction),
+	}
+	if err := t.writer.Write(record); err != nil {
+		return fmt.Errorf("writing transaction %d: %w", transaction.ID, err)
+	}
+	return nil
+}
+
+func (t *csvTransactionWriter) Close() error {
+	t.writer.Flush()
+	return t.writer.Error()
+}
+
+// exportRecord is a transaction in the JSON input format, with signed string amounts
+type exportRecord struct {
+	ID          int    `json:"id"`
+	Date        string `json:"date"`
+	Amount      string `json:"amount"`
+	Reference   string `json:"reference,omitempty"`
+	Description string `json:"description,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+}
+
+func newExportRecord(transaction domain.Transaction) exportRecord {
+	return exportRecord{
+		ID:          transaction.ID,
+		Date:        transaction.Date.Format("2006-01-02"),
+		Amount:      FormatSignedAmount(transaction),
+		Reference:   transaction.Reference,
+		Description: transaction.Description,
+		Currency:    transaction.Currency,
+	}
+}
+
+// jsonTransactionWriter writes a JSON array, one element per line
+type jsonTransactionWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (t *jsonTransactionWriter) Write(transaction domain.Transaction) error {
+	element, err := json.Marshal(newExportRecord(transaction))
+	if err != nil {
+		return fmt.Errorf("encoding transaction %d: %w", transaction.ID, err)
+	}
+
+	separator := ",\n"
+	if t.count == 0 {
+		separator = "[\n"
+	}
+	t.count++
+
+	if _, err := io.WriteString(t.w, separator); err != nil {
+		return fmt.Errorf("writing transaction %d: %w", transaction.ID, err)
+	}
+	if _, err := t.w.Write(element); err != nil {
+		return fmt.Errorf("writing transaction %d: %w", transaction.ID, err)
+	}
+	return nil
+}
+
+func (t *jsonTransactionWriter) Close() error {
+	end := "\n]\n"
+	if t.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(t.w, end)
+	return err
+}
+
+// ndjsonTransactionWriter writes one JSON object per line
+type ndjsonTransactionWriter struct {
+	encoder *json.Encoder
+}
+
+func (t *ndjsonTransactionWriter) Write(transaction domain.Transaction) error {
+	if err := t.encoder.Encode(newExportRecord(transaction)); err != nil {
+		return fmt.Errorf("writing transaction %d: %w", transaction.ID, err)
+	}
+	return nil
+}
+
+func (t *ndjsonTransactionWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,410 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// maxXLSXSize bounds the size of a workbook read into memory
+	maxXLSXSize = 64 << 20
+	// maxXLSXColumns is the number of columns of an Excel sheet (A to XFD)
+	maxXLSXColumns = 16384
+)
+
+// Excel serial dates count days from these epochs. The 1900 system treats 1900 as a leap
+// year, which the 1899-12-30 epoch accounts for on every date after February 1900
+var (
+	excelEpoch1900 = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	excelEpoch1904 = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// xlsxParser reads transactions from a sheet of an Office Open XML workbook with the
+// same Id,Date,Transaction header as CSV files
+type xlsxParser struct {
+	// sheet is the name of the sheet to read, the first sheet when empty
+	sheet string
+	// maxSize bounds the decompressed size of the workbook parts read
+	maxSize int64
+	logger  *slog.Logger
+}
+
+func (p *xlsxParser) Name() string { return "xlsx" }
+
+func (p *xlsxParser) Extensions() []string { return []string{".xlsx"} }
+
+// Sniff recognizes a ZIP archive holding workbook parts
+func (p *xlsxParser) Sniff(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("PK\x03\x04")) &&
+		(bytes.Contains(head, []byte("[Content_Types].xml")) || bytes.Contains(head, []byte("xl/")))
+}
+
+// Parse reads the configured sheet, skipping rows that are not valid transactions
+func (p *xlsxParser) Parse(ctx context.Context, r io.Reader, source string) ([]domain.Transaction, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxXLSXSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+	if len(content) > maxXLSXSize {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", domain.ErrInvalidFileFormat, source, maxXLSXSize)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s is not a workbook: %v", domain.ErrInvalidFileFormat, source, err)
+	}
+	book := &workbook{
+		files:  make(map[string]*zip.File, len(archive.File)),
+		budget: &sizeBudget{remaining: p.maxSize},
+	}
+	for _, file := range archive.File {
+		book.files[file.Name] = file
+	}
+
+	sheetPath, epoch, err := book.sheet(p.sheet)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", domain.ErrInvalidFileFormat, source, err)
+	}
+	sharedStrings, err := book.sharedStrings()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", domain.ErrInvalidFileFormat, source, err)
+	}
+
+	var (
+		transactions []domain.Transaction
+		header       bool
+	)
+	err = book.rows(sheetPath, func(number int, cells []xlsxCell) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		values := make([]string, len(cells))
+		for i, cell := range cells {
+			values[i] = cell.text(sharedStrings)
+		}
+		if isBlankRow(values) {
+			return nil
+		}
+
+		if !header {
+			p.logger.Debug("XLSX header read", "header", values)
+			if !validateHeader(trimTrailingBlanks(values), csvHeader) {
+				return fmt.Errorf("invalid XLSX header in %s, expected %v, got %v", source, csvHeader, values)
+			}
+			header = true
+			return nil
+		}
+
+		for len(cells) < len(csvHeader) {
+			cells = append(cells, xlsxCell{})
+		}
+		id := cells[0].text(sharedStrings)
+		date := cells[1].dateText(sharedStrings, epoch)
+		amount := cells[2].amountText(sharedStrings)
+
+		transaction, err := domain.NewTransaction(id, date, amount)
+		if err != nil {
+			p.logger.Warn("skipping invalid transaction", "row", number, "error", err, "record", values, "file", source)
+			return nil
+		}
+		transactions = append(transactions, *transaction)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, fmt.Errorf("reading header from %s: %w", source, io.EOF)
+	}
+
+	return transactions, nil
+}
+
+// workbook gives access to the parts of an XLSX archive
+type workbook struct {
+	files map[string]*zip.File
+	// budget bounds the decompressed size of the parts read
+	budget *sizeBudget
+}
+
+// open opens a part of the workbook, failing reads past the size budget
+func (b *workbook) open(name string) (io.ReadCloser, error) {
+	file, ok := b.files[name]
+	if !ok {
+		return nil, fmt.Errorf("missing part %s", name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", name, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&limitedReader{r: reader, budget: b.budget, source: name}, reader}, nil
+}
+
+// decode unmarshals an XML part of the workbook into v
+func (b *workbook) decode(name string, v any) error {
+	reader, err := b.open(name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", name, err)
+	}
+	return nil
+}
+
+// sheet returns the part path of the named sheet (the first one when name is empty) and
+// the epoch of the workbook's date system
+func (b *workbook) sheet(name string) (string, time.Time, error) {
+	var book struct {
+		Properties struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			// RelationshipID is the r:id attribute, matched by local name
+			RelationshipID string `xml:"id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := b.decode("xl/workbook.xml", &book); err != nil {
+		return "", time.Time{}, err
+	}
+
+	epoch := excelEpoch1900
+	if book.Properties.Date1904 == "1" || book.Properties.Date1904 == "true" {
+		epoch = excelEpoch1904
+	}
+
+	relationshipID := ""
+	for _, sheet := range book.Sheets {
+		if name == "" || sheet.Name == name {
+			relationshipID = sheet.RelationshipID
+			break
+		}
+	}
+	if relationshipID == "" {
+		if name == "" {
+			return "", time.Time{}, fmt.Errorf("workbook has no sheets")
+		}
+		return "", time.Time{}, fmt.Errorf("workbook has no sheet %q", name)
+	}
+
+	var relationships struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := b.decode("xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return "", time.Time{}, err
+	}
+	for _, relationship := range relationships.Items {
+		if relationship.ID != relationshipID {
+			continue
+		}
+		// Targets are relative to xl/, or absolute within the package
+		if strings.HasPrefix(relationship.Target, "/") {
+			return strings.TrimPrefix(relationship.Target, "/"), epoch, nil
+		}
+		return path.Join("xl", relationship.Target), epoch, nil
+	}
+	return "", time.Time{}, fmt.Errorf("sheet relationship %s not found", relationshipID)
+}
+
+// sharedStrings returns the shared string table, empty when the workbook has none
+func (b *workbook) sharedStrings() ([]string, error) {
+	if _, ok := b.files["xl/sharedStrings.xml"]; !ok {
+		return nil, nil
+	}
+
+	var table struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := b.decode("xl/sharedStrings.xml", &table); err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		strs[i] = item.String()
+	}
+	return strs, nil
+}
+
+// rows streams the rows of a sheet to fn, with cells placed at their column index
+func (b *workbook) rows(sheetPath string, fn func(number int, cells []xlsxCell) error) error {
+	reader, err := b.open(sheetPath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidFileFormat, err)
+	}
+	defer reader.Close()
+
+	decoder := xml.NewDecoder(reader)
+	number := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: decoding %s: %w", domain.ErrInvalidFileFormat, sheetPath, err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row struct {
+			Number int        `xml:"r,attr"`
+			Cells  []xlsxCell `xml:"c"`
+		}
+		if err := decoder.DecodeElement(&row, &start); err != nil {
+			return fmt.Errorf("%w: decoding %s: %w", domain.ErrInvalidFileFormat, sheetPath, err)
+		}
+		number++
+		if row.Number > 0 {
+			number = row.Number
+		}
+
+		// Cells without a value may be omitted, so place each one by its reference
+		var cells []xlsxCell
+		for i, cell := range row.Cells {
+			column := i
+			if cell.Ref != "" {
+				column = columnIndex(cell.Ref)
+			}
+			if column < 0 || column >= maxXLSXColumns {
+				continue
+			}
+			for len(cells) <= column {
+				cells = append(cells, xlsxCell{})
+			}
+			cells[column] = cell
+		}
+
+		if err := fn(number, cells); err != nil {
+			return err
+		}
+	}
+}
+
+// xlsxText is rich or plain text: a single t element, or runs each holding one
+type xlsxText struct {
+	Text string   `xml:"t"`
+	Runs []string `xml:"r>t"`
+}
+
+func (t xlsxText) String() string {
+	return t.Text + strings.Join(t.Runs, "")
+}
+
+// xlsxCell is a cell of a sheet
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+// numeric reports whether the cell holds a number
+func (c xlsxCell) numeric() bool {
+	return (c.Type == "" || c.Type == "n") && c.Value != ""
+}
+
+// text returns the displayed text of the cell, numbers in their shortest form
+func (c xlsxCell) text(sharedStrings []string) string {
+	switch c.Type {
+	case "s":
+		index, err := strconv.Atoi(c.Value)
+		if err != nil || index < 0 || index >= len(sharedStrings) {
+			return ""
+		}
+		return strings.TrimSpace(sharedStrings[index])
+	case "inlineStr":
+		return strings.TrimSpace(c.Inline.String())
+	}
+
+	if c.numeric() {
+		if value, err := strconv.ParseFloat(c.Value, 64); err == nil {
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	}
+	return strings.TrimSpace(c.Value)
+}
+
+// dateText returns the date of the cell in a format accepted by domain.NewTransaction,
+// converting serial dates of numeric cells
+func (c xlsxCell) dateText(sharedStrings []string, epoch time.Time) string {
+	if !c.numeric() {
+		return c.text(sharedStrings)
+	}
+	serial, err := strconv.ParseFloat(c.Value, 64)
+	if err != nil || serial < 1 {
+		return c.text(sharedStrings)
+	}
+	return epoch.AddDate(0, 0, int(math.Floor(serial))).Format("2006-01-02")
+}
+
+// amountText returns the signed amount of the cell: numeric cells are credits unless negative.
+// Numeric values are stored as floats, so they are rounded to cents, which also drops
+// exponents and float artifacts such as 10.300000000000001
+func (c xlsxCell) amountText(sharedStrings []string) string {
+	if c.numeric() {
+		if amount, err := decimal.NewFromString(strings.TrimSpace(c.Value)); err == nil {
+			amount = amount.Round(2)
+			if amount.IsNegative() {
+				return amount.String()
+			}
+			return "+" + amount.String()
+		}
+	}
+	return c.text(sharedStrings)
+}
+
+// columnIndex returns the zero-based column of a cell reference such as "B7"
+func columnIndex(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+	}
+	return column - 1
+}
+
+func isBlankRow(values []string) bool {
+	for _, value := range values {
+		if value != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// trimTrailingBlanks drops empty cells after the last value, as left by formatted columns
+func trimTrailingBlanks(values []string) []string {
+	for len(values) > 0 && values[len(values)-1] == "" {
+		values = values[:len(values)-1]
+	}
+	return values
+}
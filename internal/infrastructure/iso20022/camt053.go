@@ -0,0 +1,366 @@
+// Package iso20022 reads and writes ISO 20022 camt.053 bank-to-customer statements
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// Namespace is the camt.053 version written by WriteStatement. Any version is read,
+// since elements are matched by local name
+const Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// Credit/debit indicators and balance type codes
+const (
+	credit          = "CRDT"
+	debit           = "DBIT"
+	booked          = "BOOK"
+	openingBooked   = "OPBD"
+	closingBooked   = "CLBD"
+	isoDate         = "2006-01-02"
+	isoDateTime     = "2006-01-02T15:04:05"
+	defaultCurrency = "USD"
+)
+
+// Document is the root of a camt.053 message
+type Document struct {
+	XMLName   xml.Name           `xml:"Document"`
+	Namespace string             `xml:"xmlns,attr,omitempty"`
+	Message   BankToCustomerStmt `xml:"BkToCstmrStmt"`
+}
+
+// BankToCustomerStmt holds the statements of a message
+type BankToCustomerStmt struct {
+	GroupHeader GroupHeader `xml:"GrpHdr"`
+	Statements  []Statement `xml:"Stmt"`
+}
+
+// GroupHeader identifies the message
+type GroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	Created   string `xml:"CreDtTm"`
+}
+
+// Statement is the statement of one account over a period
+type Statement struct {
+	ID       string        `xml:"Id"`
+	Created  string        `xml:"CreDtTm"`
+	Period   *DateTimeSpan `xml:"FrToDt,omitempty"`
+	Account  Account       `xml:"Acct"`
+	Balances []Balance     `xml:"Bal"`
+	Entries  []Entry       `xml:"Ntry"`
+}
+
+// DateTimeSpan is the period covered by a statement
+type DateTimeSpan struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+// Account identifies the account of a statement
+type Account struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy,omitempty"`
+}
+
+// Balance is a balance of the account at a date, e.g. the opening or closing booked balance
+type Balance struct {
+	Type      string `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    Amount `xml:"Amt"`
+	Indicator string `xml:"CdtDbtInd"`
+	Date      Date   `xml:"Dt"`
+}
+
+// Amount is an amount in a currency
+type Amount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// Date holds a date (Dt) or a date and time (DtTm)
+type Date struct {
+	Date     string `xml:"Dt,omitempty"`
+	DateTime string `xml:"DtTm,omitempty"`
+}
+
+// Status is the entry status: plain text up to camt.053.001.04, a Cd element from version 05
+type Status struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd,omitempty"`
+}
+
+// Entry is a movement on the account
+type Entry struct {
+	Reference       string          `xml:"NtryRef,omitempty"`
+	Amount          Amount          `xml:"Amt"`
+	Indicator       string          `xml:"CdtDbtInd"`
+	Status          Status          `xml:"Sts"`
+	BookingDate     Date            `xml:"BookgDt"`
+	ValueDate       *Date           `xml:"ValDt,omitempty"`
+	ServicerRef     string          `xml:"AcctSvcrRef,omitempty"`
+	TransactionCode TransactionCode `xml:"BkTxCd"`
+	Details         []EntryDetails  `xml:"NtryDtls,omitempty"`
+	AdditionalInfo  string          `xml:"AddtlNtryInf,omitempty"`
+}
+
+// TransactionCode is the bank transaction code of an entry
+type TransactionCode struct {
+	Domain    string `xml:"Domn>Cd,omitempty"`
+	Family    string `xml:"Domn>Fmly>Cd,omitempty"`
+	SubFamily string `xml:"Domn>Fmly>SubFmlyCd,omitempty"`
+}
+
+// EntryDetails holds the underlying transactions of an entry
+type EntryDetails struct {
+	Transactions []TransactionDetails `xml:"TxDtls"`
+}
+
+// TransactionDetails holds the references and remittance information of a transaction
+type TransactionDetails struct {
+	ServicerRef string   `xml:"Refs>AcctSvcrRef,omitempty"`
+	EndToEndID  string   `xml:"Refs>EndToEndId,omitempty"`
+	Remittance  []string `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+// Decode reads a camt.053 document
+func Decode(r io.Reader) (*Document, error) {
+	var document Document
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("%w: decoding camt.053: %w", domain.ErrInvalidFileFormat, err)
+	}
+	if len(document.Message.Statements) == 0 {
+		return nil, fmt.Errorf("%w: camt.053 document has no statements", domain.ErrInvalidFileFormat)
+	}
+	return &document, nil
+}
+
+// Booked reports whether the entry is booked, as opposed to pending or informational
+func (e Entry) Booked() bool {
+	status := strings.TrimSpace(e.Status.Code)
+	if status == "" {
+		status = strings.TrimSpace(e.Status.Text)
+	}
+	return status == "" || status == booked
+}
+
+// Transaction converts the entry into a transaction dated on its booking date. A numeric
+// NtryRef is the transaction ID, as written by WriteStatement; otherwise the ID is derived
+// from the reference, which is what identifies the transaction once persisted. An entry
+// whose only reference is a numeric NtryRef has no reference, like a CSV row
+func (e Entry) Transaction() (*domain.Transaction, error) {
+	reference := e.reference()
+	id, ok := e.entryID()
+	switch {
+	case ok:
+	case reference != "":
+		id = domain.TransactionIDFromReference(reference)
+	default:
+		return nil, domain.ErrInvalidTransactionID
+	}
+
+	date := e.BookingDate.Date
+	if date == "" && len(e.BookingDate.DateTime) >= len(isoDate) {
+		date = e.BookingDate.DateTime[:len(isoDate)]
+	}
+
+	var sign string
+	switch strings.TrimSpace(e.Indicator) {
+	case credit:
+		sign = "+"
+	case debit:
+		sign = "-"
+	default:
+		return nil, domain.ErrInvalidAmount
+	}
+
+	transaction, err := domain.NewTransaction(
+		strconv.Itoa(id),
+		date,
+		sign+strings.TrimSpace(e.Amount.Value),
+	)
+	if err != nil {
+		return nil, err
+	}
+	transaction.Reference = reference
+	transaction.Description = e.description()
+	transaction.Currency = strings.ToUpper(strings.TrimSpace(e.Amount.Currency))
+
+	return transaction, nil
+}
+
+// entryID returns the NtryRef as a transaction ID when it is a number
+func (e Entry) entryID() (int, bool) {
+	id, err := strconv.Atoi(strings.TrimSpace(e.Reference))
+	return id, err == nil && id >= 0 && id <= math.MaxInt32
+}
+
+// reference returns the first reference of the entry: the servicer's, the entry's own
+// unless it is a transaction ID, then those of its underlying transaction
+func (e Entry) reference() string {
+	candidates := []string{e.ServicerRef}
+	if _, ok := e.entryID(); !ok {
+		candidates = append(candidates, e.Reference)
+	}
+	for _, details := range e.Details {
+		for _, transaction := range details.Transactions {
+			candidates = append(candidates, transaction.ServicerRef, transaction.EndToEndID)
+		}
+	}
+	for _, candidate := range candidates {
+		if candidate = strings.TrimSpace(candidate); candidate != "" {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// description returns the additional entry information, or the unstructured remittance
+// information of the underlying transactions
+func (e Entry) description() string {
+	if info := strings.TrimSpace(e.AdditionalInfo); info != "" {
+		return info
+	}
+	var lines []string
+	for _, details := range e.Details {
+		for _, transaction := range details.Transactions {
+			for _, line := range transaction.Remittance {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// ErrMixedCurrencies is returned when the transactions of a statement are not all in its
+// currency, as its balances cannot add them up
+var ErrMixedCurrencies = errors.New("statement transactions are in several currencies")
+
+// StatementData is the content of a statement to export
+type StatementData struct {
+	// ID identifies the statement and its message
+	ID string
+	// AccountID identifies the account in the statement
+	AccountID string
+	// Currency is the currency of the statement, also assumed for transactions that do
+	// not state their own. Empty takes the currency of the transactions, else USD
+	Currency string
+	// From and To are the first and last day covered by the statement
+	From    time.Time
+	To      time.Time
+	Created time.Time
+	// OpeningBalance is the booked balance before the first transaction
+	OpeningBalance decimal.Decimal
+	Transactions   []domain.Transaction
+}
+
+// WriteStatement writes a camt.053 document with a single statement holding the given
+// transactions, opening and closing booked balances. It fails with ErrMixedCurrencies
+// when a transaction is in another currency than the statement
+func WriteStatement(w io.Writer, data StatementData) error {
+	currency := strings.ToUpper(data.Currency)
+	for _, transaction := range data.Transactions {
+		if currency == "" {
+			currency = strings.ToUpper(transaction.Currency)
+		}
+	}
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	for _, transaction := range data.Transactions {
+		if transaction.Currency != "" && !strings.EqualFold(transaction.Currency, currency) {
+			return fmt.Errorf("%w: transaction %d is in %s, the statement in %s", ErrMixedCurrencies, transaction.ID, transaction.Currency, currency)
+		}
+	}
+
+	closing := data.OpeningBalance
+	entries := make([]Entry, 0, len(data.Transactions))
+	for _, transaction := range data.Transactions {
+		closing = closing.Add(transaction.SignedAmount())
+		entries = append(entries, newEntry(transaction, currency))
+	}
+
+	document := Document{
+		Namespace: Namespace,
+		Message: BankToCustomerStmt{
+			GroupHeader: GroupHeader{
+				MessageID: data.ID,
+				Created:   data.Created.Format(isoDateTime),
+			},
+			Statements: []Statement{{
+				ID:      data.ID,
+				Created: data.Created.Format(isoDateTime),
+				Period: &DateTimeSpan{
+					From: data.From.Format(isoDateTime),
+					To:   data.To.Format(isoDate) + "T23:59:59",
+				},
+				Account: Account{ID: data.AccountID, Currency: currency},
+				Balances: []Balance{
+					newBalance(openingBooked, data.OpeningBalance, currency, data.From),
+					newBalance(closingBooked, closing, currency, data.To),
+				},
+				Entries: entries,
+			}},
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("writing camt.053 header: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("encoding camt.053: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func newBalance(balanceType string, amount decimal.Decimal, currency string, date time.Time) Balance {
+	indicator := credit
+	if amount.IsNegative() {
+		indicator = debit
+	}
+	return Balance{
+		Type:      balanceType,
+		Amount:    Amount{Value: amount.Abs().StringFixed(2), Currency: currency},
+		Indicator: indicator,
+		Date:      Date{Date: date.Format(isoDate)},
+	}
+}
+
+// newEntry writes the transaction ID as the NtryRef and its reference, if any, as the
+// AcctSvcrRef, so that Entry.Transaction reads both back unchanged
+func newEntry(transaction domain.Transaction, currency string) Entry {
+	indicator, family := credit, "RCDT"
+	if transaction.IsDebit() {
+		indicator, family = debit, "ICDT"
+	}
+
+	date := Date{Date: transaction.Date.Format(isoDate)}
+	return Entry{
+		Reference:   strconv.Itoa(transaction.ID),
+		Amount:      Amount{Value: transaction.Amount.StringFixed(2), Currency: currency},
+		Indicator:   indicator,
+		Status:      Status{Text: booked},
+		BookingDate: date,
+		ValueDate:   &date,
+		ServicerRef: transaction.Reference,
+		TransactionCode: TransactionCode{
+			Domain:    "PMNT",
+			Family:    family,
+			SubFamily: "OTHR",
+		},
+		AdditionalInfo: transaction.Description,
+	}
+}
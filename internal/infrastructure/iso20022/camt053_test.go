@@ -0,0 +1,127 @@
+package iso20022
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func TestWriteStatementRoundTrip(t *testing.T) {
+	transactions := []domain.Transaction{
+		{ID: 1, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("60.5"), Type: domain.Credit, Description: "Salary"},
+		{ID: 2, Date: time.Date(2024, 7, 28, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("10.3"), Type: domain.Debit, Reference: "REF-2", Currency: "EUR"},
+		{ID: 3, Date: time.Date(2024, 7, 30, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("5"), Type: domain.Credit, Reference: "FITID-77", Currency: "EUR"},
+	}
+
+	var buf bytes.Buffer
+	err := WriteStatement(&buf, StatementData{
+		ID:             "STMT-2024-07",
+		AccountID:      "account-1",
+		From:           time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC),
+		Created:        time.Date(2024, 8, 1, 9, 0, 0, 0, time.UTC),
+		OpeningBalance: decimal.RequireFromString("-20"),
+		Transactions:   transactions,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		`<Document xmlns="` + Namespace + `">`,
+		`<Ccy>EUR</Ccy>`,
+		`<Cd>OPBD</Cd>`, `<Amt Ccy="EUR">20.00</Amt>`, `<CdtDbtInd>DBIT</CdtDbtInd>`,
+		`<Cd>CLBD</Cd>`, `<Amt Ccy="EUR">35.20</Amt>`,
+		`<Amt Ccy="EUR">60.50</Amt>`, `<Amt Ccy="EUR">10.30</Amt>`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("statement does not contain %s:\n%s", want, output)
+		}
+	}
+
+	document, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := document.Message.Statements[0].Entries
+	if len(entries) != len(transactions) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(transactions))
+	}
+	for i, entry := range entries {
+		got, err := entry.Transaction()
+		if err != nil {
+			t.Fatalf("entry %d: %v", i, err)
+		}
+		want := transactions[i]
+		if got.ID != want.ID || got.Reference != want.Reference || !got.Date.Equal(want.Date) || !got.Amount.Equal(want.Amount) ||
+			got.Type != want.Type || got.Description != want.Description || got.Currency != "EUR" {
+			t.Errorf("entry %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestWriteStatementMixedCurrencies(t *testing.T) {
+	err := WriteStatement(io.Discard, StatementData{
+		ID:       "STMT-2024-07",
+		Currency: "USD",
+		Transactions: []domain.Transaction{
+			{ID: 1, Amount: decimal.RequireFromString("60.5"), Type: domain.Credit},
+			{ID: 2, Amount: decimal.RequireFromString("10.3"), Type: domain.Debit, Currency: "EUR"},
+		},
+	})
+	if !errors.Is(err, ErrMixedCurrencies) {
+		t.Errorf("error = %v, want ErrMixedCurrencies", err)
+	}
+}
+
+func TestDecodeLaterVersion(t *testing.T) {
+	document, err := Decode(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>MSG</MsgId><CreDtTm>2024-08-01T00:00:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>S1</Id>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Ntry>
+        <Amt Ccy="EUR">42.00</Amt><CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2024-07-03T10:15:00+02:00</DtTm></BookgDt>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>E2E-1</EndToEndId></Refs>
+          <RmtInf><Ustrd>Invoice 17</Ustrd><Ustrd>July</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">5.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>PDNG</Cd></Sts>
+        <BookgDt><Dt>2024-07-04</Dt></BookgDt>
+        <AcctSvcrRef>P-1</AcctSvcrRef>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := document.Message.Statements[0].Entries
+	if !entries[0].Booked() || entries[1].Booked() {
+		t.Fatalf("booked = %v, %v; want true, false", entries[0].Booked(), entries[1].Booked())
+	}
+
+	got, err := entries[0].Transaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Reference != "E2E-1" || !got.IsDebit() || got.Amount.String() != "42" ||
+		got.Date.Format("2006-01-02") != "2024-07-03" || got.Description != "Invoice 17 July" {
+		t.Errorf("transaction = %+v", got)
+	}
+}
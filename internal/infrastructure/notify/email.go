@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+type emailNotifier struct {
+	emailService services.EmailService
+}
+
+// NewEmailNotifier creates a notifier that sends the summary email through the email service
+func NewEmailNotifier(emailService services.EmailService) services.Notifier {
+	return &emailNotifier{
+		emailService: emailService,
+	}
+}
+
+// Name returns the notifier name
+func (n *emailNotifier) Name() string {
+	return ChannelSMTP
+}
+
+// Notify sends the summary email to the notification recipient
+func (n *emailNotifier) Notify(ctx context.Context, notification services.Notification) error {
+	return n.emailService.SendSummary(ctx, notification.Recipient, notification.Summary, notification.Transactions)
+}
+
+// Close releases the resources held by the email service
+func (n *emailNotifier) Close() error {
+	if closer, ok := n.emailService.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+const (
+	LayoutFlat  = "flat"
+	LayoutSpool = "spool"
+)
+
+type FileSinkConfig struct {
+	Directory string
+	// Layout is either "flat" (one JSON file per notification in Directory) or
+	// "spool" (JSON files are written to tmp/ and atomically moved to new/, so a
+	// consumer watching new/ never sees a partial file)
+	Layout string
+}
+
+type fileNotifier struct {
+	config FileSinkConfig
+	logger *slog.Logger
+}
+
+// NewFileNotifier creates a notifier that writes each summary as a JSON document into a local directory
+func NewFileNotifier(config FileSinkConfig, logger *slog.Logger) services.Notifier {
+	return &fileNotifier{
+		config: config,
+		logger: logger,
+	}
+}
+
+// Name returns the notifier name
+func (n *fileNotifier) Name() string {
+	return ChannelFile
+}
+
+// Notify writes the notification payload to the sink directory
+func (n *fileNotifier) Notify(ctx context.Context, notification services.Notification) error {
+	body, err := json.MarshalIndent(newPayload(notification), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding notification: %w", err)
+	}
+
+	name, err := uniqueName()
+	if err != nil {
+		return err
+	}
+
+	tmpDir, finalDir := n.config.Directory, n.config.Directory
+	if n.config.Layout == LayoutSpool {
+		tmpDir = filepath.Join(n.config.Directory, "tmp")
+		finalDir = filepath.Join(n.config.Directory, "new")
+	}
+
+	for _, dir := range []string{tmpDir, finalDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating directory %s: %w", dir, err)
+		}
+	}
+
+	tmpPath := filepath.Join(tmpDir, "."+name+".tmp")
+	finalPath := filepath.Join(finalDir, name+".json")
+
+	if err := os.WriteFile(tmpPath, body, 0o644); err != nil {
+		return fmt.Errorf("writing notification file: %w", err)
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("moving notification file into place: %w", err)
+	}
+
+	n.logger.Debug("notification written", "file", finalPath, "recipient", notification.Recipient)
+	return nil
+}
+
+// uniqueName builds a unique file name (timestamp, random suffix and hostname)
+func uniqueName() (string, error) {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("generating file name: %w", err)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+
+	return fmt.Sprintf("%d.%s.%s", time.Now().UnixNano(), hex.EncodeToString(suffix), hostname), nil
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+	"github.com/shopspring/decimal"
+)
+
+func TestFileNotifierLayouts(t *testing.T) {
+	transactions := []domain.Transaction{
+		{ID: 1, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("60.5"), Type: domain.Credit},
+		{ID: 2, Date: time.Date(2024, 7, 28, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("10.3"), Type: domain.Debit},
+	}
+	notification := services.Notification{
+		Recipient:    "user@example.com",
+		Source:       "transactions.csv",
+		Summary:      domain.NewSummary(transactions),
+		Transactions: transactions,
+	}
+	// Timestamp, random suffix and hostname
+	namePattern := regexp.MustCompile(`^\d+\.[0-9a-f]{12}\..+\.json$`)
+
+	tests := []struct {
+		name   string
+		layout string
+		// dir is where notifications end up; empty, when set, must be left without files
+		dir, empty string
+	}{
+		{name: "flat", layout: LayoutFlat, dir: "."},
+		{name: "default", layout: "", dir: "."},
+		{name: "spool", layout: LayoutSpool, dir: "new", empty: "tmp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := t.TempDir()
+			notifier := NewFileNotifier(FileSinkConfig{Directory: directory, Layout: tt.layout}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			for range 2 {
+				if err := notifier.Notify(context.Background(), notification); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			entries, err := os.ReadDir(filepath.Join(directory, tt.dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					files = append(files, entry.Name())
+				}
+			}
+			if len(files) != 2 {
+				t.Fatalf("files in %s = %v, want one per notification", tt.dir, files)
+			}
+
+			for _, name := range files {
+				if !namePattern.MatchString(name) {
+					t.Errorf("file name %s does not match %s", name, namePattern)
+				}
+				body, err := os.ReadFile(filepath.Join(directory, tt.dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var written payload
+				if err := json.Unmarshal(body, &written); err != nil {
+					t.Fatalf("invalid JSON payload: %v", err)
+				}
+				if written.Recipient != "user@example.com" || written.Source != "transactions.csv" || written.TransactionCount != 2 {
+					t.Errorf("payload = %+v", written)
+				}
+				if written.Summary == nil || !written.Summary.TotalBalance.Equal(decimal.RequireFromString("50.2")) {
+					t.Errorf("summary = %+v, want a total balance of 50.2", written.Summary)
+				}
+			}
+
+			if tt.empty != "" {
+				leftover, err := os.ReadDir(filepath.Join(directory, tt.empty))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(leftover) > 0 {
+					t.Errorf("%s holds %d files, want none", tt.empty, len(leftover))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+const (
+	ChannelSMTP    = "smtp"
+	ChannelWebhook = "webhook"
+	ChannelFile    = "file"
+)
+
+// payload is the JSON document delivered by the webhook and file channels
+type payload struct {
+	Recipient        string          `json:"recipient"`
+	Source           string          `json:"source"`
+	TransactionCount int             `json:"transaction_count"`
+	Summary          *domain.Summary `json:"summary"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+func newPayload(notification services.Notification) payload {
+	return payload{
+		Recipient:        notification.Recipient,
+		Source:           notification.Source,
+		TransactionCount: len(notification.Transactions),
+		Summary:          notification.Summary,
+		CreatedAt:        time.Now().UTC(),
+	}
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/services"
+)
+
+const (
+	SignatureHeader = "X-Stori-Signature"
+	TimestampHeader = "X-Stori-Timestamp"
+)
+
+type WebhookConfig struct {
+	URL     string
+	Secret  string
+	Timeout time.Duration
+}
+
+type webhookNotifier struct {
+	config WebhookConfig
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewWebhookNotifier creates a notifier that POSTs the summary as JSON to an HTTP endpoint
+func NewWebhookNotifier(config WebhookConfig, logger *slog.Logger) services.Notifier {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &webhookNotifier{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+// Name returns the notifier name
+func (n *webhookNotifier) Name() string {
+	return ChannelWebhook
+}
+
+// Notify posts the summary to the webhook URL. When a secret is configured the request
+// carries an HMAC-SHA256 signature of "<timestamp>.<body>" in the X-Stori-Signature header
+func (n *webhookNotifier) Notify(ctx context.Context, notification services.Notification) error {
+	body, err := json.Marshal(newPayload(notification))
+	if err != nil {
+		return fmt.Errorf("encoding webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	if n.config.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(n.config.Secret, timestamp, body))
+	}
+
+	n.logger.Debug("posting webhook notification", "url", n.config.URL, "recipient", notification.Recipient)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign computes the hex encoded HMAC-SHA256 signature of a webhook body
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
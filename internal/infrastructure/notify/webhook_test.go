@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/services"
+	"github.com/shopspring/decimal"
+)
+
+func TestWebhookNotifierSignsPayload(t *testing.T) {
+	const secret = "top-secret"
+
+	var received payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		want := "sha256=" + Sign(secret, r.Header.Get(TimestampHeader), body)
+		if got := r.Header.Get(SignatureHeader); got != want {
+			t.Errorf("signature = %s, want %s", got, want)
+		}
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("invalid JSON payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	transactions := []domain.Transaction{
+		{ID: 1, Date: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("60.5"), Type: domain.Credit},
+	}
+
+	notifier := NewWebhookNotifier(WebhookConfig{URL: server.URL, Secret: secret}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	err := notifier.Notify(context.Background(), services.Notification{
+		Recipient:    "user@example.com",
+		Source:       "transactions.csv",
+		Summary:      domain.NewSummary(transactions),
+		Transactions: transactions,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received.Recipient != "user@example.com" {
+		t.Errorf("Recipient = %s, want user@example.com", received.Recipient)
+	}
+	if received.TransactionCount != 1 {
+		t.Errorf("TransactionCount = %d, want 1", received.TransactionCount)
+	}
+}
+
+func TestWebhookNotifierRejectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(WebhookConfig{URL: server.URL}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	err := notifier.Notify(context.Background(), services.Notification{Summary: domain.NewSummary(nil)})
+	if err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
@@ -0,0 +1,304 @@
+// Package statement renders printable account statements
+package statement
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/pdf"
+	"github.com/shopspring/decimal"
+)
+
+// Page layout, in points from the top-left corner
+const (
+	marginX    = 50.0
+	rowHeight  = 16.0
+	fontSize   = 9.0
+	bottomEdge = pdf.PageHeight - 70
+	footerY    = pdf.PageHeight - 35
+	right      = pdf.PageWidth - marginX
+
+	// Transaction table columns: left edges, or right edges for amounts
+	columnDate        = marginX + 5
+	columnID          = marginX + 75
+	columnDescription = marginX + 120
+	columnAmount      = right - 95
+	columnBalance     = right - 5
+	descriptionWidth  = columnAmount - 70 - columnDescription
+)
+
+// Options completes a statement beyond what the summary holds
+type Options struct {
+	Locale i18n.Locale
+	// Account names the account holder, e.g. an email; omitted when empty
+	Account string
+	// Period is the statement period, the months of the summarized transactions when nil
+	Period *domain.Period
+	// OpeningBalance is the balance before the first transaction. When nil it is derived
+	// from the summary's account balance, or zero when that is unknown
+	OpeningBalance *decimal.Decimal
+	// Generated is the generation time printed in the header, now when zero
+	Generated time.Time
+}
+
+// RenderPDF renders a paginated PDF statement of the summarized transactions: a header
+// with the period, the opening and closing balances, the transactions with a running
+// balance grouped by month, and the monthly totals
+func RenderPDF(summary *domain.Summary, transactions []domain.Transaction, options Options) ([]byte, error) {
+	if options.Locale == "" {
+		options.Locale = i18n.DefaultLocale
+	}
+	if options.Generated.IsZero() {
+		options.Generated = time.Now()
+	}
+
+	sorted := make([]domain.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	l := &layout{
+		doc:     pdf.New("Stori - " + options.Locale.T("statement.title")),
+		locale:  options.Locale,
+		options: options,
+		period:  periodLabel(summary, options),
+	}
+
+	opening := openingBalance(summary, options)
+	l.newPage()
+	l.balances(summary, opening)
+	l.transactions(sorted, opening)
+	if summary.HasTransactions() {
+		l.monthlyTotals(summary, opening)
+	}
+	l.footers()
+
+	return l.doc.Bytes()
+}
+
+// openingBalance returns the balance before the first transaction
+func openingBalance(summary *domain.Summary, options Options) decimal.Decimal {
+	switch {
+	case options.OpeningBalance != nil:
+		return *options.OpeningBalance
+	case summary.AccountBalance != nil:
+		// The account balance already includes the summarized transactions
+		return summary.AccountBalance.Sub(summary.TotalBalance)
+	default:
+		return decimal.Zero
+	}
+}
+
+func periodLabel(summary *domain.Summary, options Options) string {
+	period, ok := summary.Period()
+	if options.Period != nil {
+		period, ok = *options.Period, true
+	}
+	if !ok {
+		return "-"
+	}
+	locale := options.Locale
+	from := locale.FormatMonthYear(period.From.Year, period.From.Month)
+	if period.From == period.To {
+		return from
+	}
+	return from + " – " + locale.FormatMonthYear(period.To.Year, period.To.Month)
+}
+
+// layout places content top to bottom, starting new pages as they fill up
+type layout struct {
+	doc     *pdf.Document
+	pages   []*pdf.Page
+	page    *pdf.Page
+	y       float64
+	locale  i18n.Locale
+	options Options
+	period  string
+}
+
+func (l *layout) money(value decimal.Decimal) string {
+	return l.locale.FormatCurrency(value)
+}
+
+// newPage starts a page with the statement header; the first page carries the full header
+func (l *layout) newPage() {
+	l.page = l.doc.AddPage()
+	l.pages = append(l.pages, l.page)
+
+	l.page.Text(marginX, 60, pdf.Bold, 18, "Stori - "+l.locale.T("statement.title"))
+	l.y = 78
+	if l.options.Account != "" {
+		l.page.Text(marginX, l.y, pdf.Regular, 10, l.locale.T("statement.account", l.options.Account))
+		l.y += 14
+	}
+	l.page.Text(marginX, l.y, pdf.Regular, 10, l.locale.T("statement.period", l.period))
+	if len(l.pages) == 1 {
+		l.y += 14
+		l.page.Text(marginX, l.y, pdf.Regular, 10, l.locale.T("statement.generated", l.locale.FormatDate(l.options.Generated)))
+	}
+	l.page.Line(marginX, l.y+10, right, l.y+10, 0.8)
+	l.y += 34
+}
+
+// ensure starts a new page unless height fits above the bottom edge, and reports whether it did
+func (l *layout) ensure(height float64) bool {
+	if l.y+height <= bottomEdge {
+		return false
+	}
+	l.newPage()
+	return true
+}
+
+func (l *layout) sectionTitle(title string) {
+	l.ensure(3 * rowHeight)
+	l.page.Text(marginX, l.y, pdf.Bold, 12, title)
+	l.y += rowHeight + 4
+}
+
+func (l *layout) balances(summary *domain.Summary, opening decimal.Decimal) {
+	var credits, debits decimal.Decimal
+	for _, month := range summary.Months() {
+		credits = credits.Add(summary.MonthlyCredits[month])
+		debits = debits.Add(summary.MonthlyDebits[month])
+	}
+
+	rows := []struct {
+		label string
+		value decimal.Decimal
+		font  pdf.Font
+	}{
+		{l.locale.T("statement.opening_balance"), opening, pdf.Regular},
+		{l.locale.T("statement.total_credits"), credits, pdf.Regular},
+		{l.locale.T("statement.total_debits"), debits.Neg(), pdf.Regular},
+		{l.locale.T("statement.closing_balance"), opening.Add(summary.TotalBalance), pdf.Bold},
+	}
+
+	l.page.FillRect(marginX, l.y-14, right-marginX, float64(len(rows))*rowHeight+10, 0.95)
+	for _, row := range rows {
+		l.page.Text(marginX+10, l.y, row.font, 10, row.label)
+		l.page.TextRight(right-10, l.y, row.font, 10, l.money(row.value))
+		l.y += rowHeight
+	}
+	l.y += 20
+}
+
+func (l *layout) transactionHeader() {
+	l.page.FillRect(marginX, l.y-12, right-marginX, rowHeight, 0.88)
+	l.page.Text(columnDate, l.y, pdf.Bold, fontSize, l.locale.T("statement.column.date"))
+	l.page.Text(columnID, l.y, pdf.Bold, fontSize, l.locale.T("statement.column.id"))
+	l.page.Text(columnDescription, l.y, pdf.Bold, fontSize, l.locale.T("statement.column.description"))
+	l.page.TextRight(columnAmount, l.y, pdf.Bold, fontSize, l.locale.T("statement.column.amount"))
+	l.page.TextRight(columnBalance, l.y, pdf.Bold, fontSize, l.locale.T("statement.column.balance"))
+	l.y += rowHeight
+}
+
+// transactions lists the transactions with a running balance, under a heading per month.
+// The column header is repeated on every page the table spans
+func (l *layout) transactions(sorted []domain.Transaction, opening decimal.Decimal) {
+	l.sectionTitle(l.locale.T("statement.transactions"))
+	if len(sorted) == 0 {
+		l.page.Text(marginX+5, l.y, pdf.Regular, 10, l.locale.T("statement.no_transactions"))
+		l.y += 2 * rowHeight
+		return
+	}
+	l.transactionHeader()
+
+	balance := opening
+	var month domain.YearMonth
+	for i, transaction := range sorted {
+		newMonth := i == 0 || transaction.YearMonth() != month
+		rows := 1.0
+		if newMonth {
+			rows = 2
+		}
+		if l.ensure(rows * rowHeight) {
+			l.transactionHeader()
+		}
+
+		if newMonth {
+			month = transaction.YearMonth()
+			l.page.Text(columnDate, l.y, pdf.Bold, fontSize, l.locale.FormatMonthYear(month.Year, month.Month))
+			l.y += rowHeight
+		}
+
+		balance = balance.Add(transaction.SignedAmount())
+		l.page.Text(columnDate, l.y, pdf.Regular, fontSize, transaction.Date.Format("2006-01-02"))
+		l.page.Text(columnID, l.y, pdf.Regular, fontSize, strconv.Itoa(transaction.ID))
+		l.page.Text(columnDescription, l.y, pdf.Regular, fontSize, truncate(description(transaction), pdf.Regular, fontSize, descriptionWidth))
+		l.page.TextRight(columnAmount, l.y, pdf.Regular, fontSize, l.money(transaction.SignedAmount()))
+		l.page.TextRight(columnBalance, l.y, pdf.Regular, fontSize, l.money(balance))
+		l.page.Line(marginX, l.y+4, right, l.y+4, 0.2)
+		l.y += rowHeight
+	}
+	l.y += 20
+}
+
+// monthlyTotals lists the credits, debits, net movement and closing balance of each month
+func (l *layout) monthlyTotals(summary *domain.Summary, opening decimal.Decimal) {
+	columns := []struct {
+		label string
+		right float64
+	}{
+		{l.locale.T("email.column.credits"), right - 290},
+		{l.locale.T("email.column.debits"), right - 195},
+		{l.locale.T("email.column.net"), right - 100},
+		{l.locale.T("email.column.closing"), right - 5},
+	}
+	header := func() {
+		l.page.FillRect(marginX, l.y-12, right-marginX, rowHeight, 0.88)
+		l.page.Text(columnDate, l.y, pdf.Bold, fontSize, l.locale.T("email.column.month"))
+		for _, column := range columns {
+			l.page.TextRight(column.right, l.y, pdf.Bold, fontSize, column.label)
+		}
+		l.y += rowHeight
+	}
+
+	l.sectionTitle(l.locale.T("statement.monthly_totals"))
+	header()
+	for _, month := range summary.Monthly() {
+		if l.ensure(rowHeight) {
+			header()
+		}
+		values := []decimal.Decimal{month.Credits, month.Debits.Neg(), month.Net, opening.Add(month.ClosingBalance)}
+		l.page.Text(columnDate, l.y, pdf.Regular, fontSize, l.locale.FormatMonthYear(month.Month.Year, month.Month.Month))
+		for i, column := range columns {
+			l.page.TextRight(column.right, l.y, pdf.Regular, fontSize, l.money(values[i]))
+		}
+		l.page.Line(marginX, l.y+4, right, l.y+4, 0.2)
+		l.y += rowHeight
+	}
+}
+
+// footers numbers every page once the page count is known
+func (l *layout) footers() {
+	for i, page := range l.pages {
+		page.Line(marginX, footerY-12, right, footerY-12, 0.3)
+		page.Text(marginX, footerY, pdf.Regular, 8, "Stori")
+		page.TextRight(right, footerY, pdf.Regular, 8, l.locale.T("statement.page", i+1, len(l.pages)))
+	}
+}
+
+// description returns the bank description of a transaction, or its reference
+func description(transaction domain.Transaction) string {
+	if transaction.Description != "" {
+		return transaction.Description
+	}
+	return transaction.Reference
+}
+
+// truncate shortens text with an ellipsis to fit within width
+func truncate(text string, font pdf.Font, size, width float64) string {
+	if pdf.TextWidth(text, font, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.TextWidth(string(runes)+"...", font, size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return fmt.Sprintf("%s...", string(runes))
+}
@@ -0,0 +1,73 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
+	"github.com/shopspring/decimal"
+)
+
+func TestRenderPDF(t *testing.T) {
+	var transactions []domain.Transaction
+	start := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 120; i++ {
+		transactions = append(transactions, domain.Transaction{
+			ID:          i + 1,
+			Date:        start.AddDate(0, 0, i/2),
+			Amount:      decimal.RequireFromString("10.50"),
+			Type:        domain.TransactionType(i % 2),
+			Description: "Grocery store purchase with a very long description that does not fit in its column",
+		})
+	}
+	summary := &domain.Summary{
+		TotalBalance:        decimal.Zero,
+		MonthlyTransactions: map[domain.YearMonth]int{},
+		MonthlyCredits:      map[domain.YearMonth]decimal.Decimal{},
+		MonthlyDebits:       map[domain.YearMonth]decimal.Decimal{},
+	}
+	for _, transaction := range transactions {
+		month := transaction.YearMonth()
+		summary.MonthlyTransactions[month]++
+		summary.TotalBalance = summary.TotalBalance.Add(transaction.SignedAmount())
+		if transaction.SignedAmount().IsPositive() {
+			summary.MonthlyCredits[month] = summary.MonthlyCredits[month].Add(transaction.Amount)
+		} else {
+			summary.MonthlyDebits[month] = summary.MonthlyDebits[month].Add(transaction.Amount)
+		}
+	}
+
+	opening := decimal.RequireFromString("100")
+	data, err := RenderPDF(summary, transactions, Options{
+		Locale:         i18n.English,
+		Account:        "user@example.com",
+		OpeningBalance: &opening,
+		Generated:      time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("RenderPDF() error = %v", err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Fatalf("output does not start with a PDF header")
+	}
+	pages := bytes.Count(data, []byte("/Type /Page "))
+	if pages < 2 {
+		t.Fatalf("pages = %d, want the transactions to span several pages", pages)
+	}
+	for _, want := range []string{
+		"Account: user@example.com",
+		"Period: July 2025 \x96 August 2025",
+		"Opening balance",
+		"Monthly totals",
+		fmt.Sprintf("Page %d of %d", pages, pages),
+		"...",
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("PDF does not contain %q", want)
+		}
+	}
+}
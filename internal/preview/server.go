@@ -0,0 +1,247 @@
+// Package preview serves rendered summary emails to a browser while templates are edited
+package preview
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/NahuelDT/stori-challenge/internal/i18n"
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/email"
+)
+
+// shutdownTimeout bounds the wait for in-flight requests when the server stops
+const shutdownTimeout = 5 * time.Second
+
+// DefaultAddr is the address the preview server listens on unless configured, keeping
+// previews off the network
+const DefaultAddr = "localhost"
+
+// Config configures the preview server
+type Config struct {
+	// Addr is the host or IP to listen on, DefaultAddr when empty
+	Addr string
+	Port string
+}
+
+// Sample is a named summary to preview. Load is called on every request, so samples read
+// from files pick up their changes too
+type Sample struct {
+	Name string
+	Load func(ctx context.Context) (*domain.Summary, error)
+}
+
+// FixtureSamples returns the built-in fixture summaries as samples
+func FixtureSamples() []Sample {
+	var samples []Sample
+	for _, fixture := range email.Fixtures() {
+		summary := fixture.Summary
+		samples = append(samples, Sample{
+			Name: fixture.Name,
+			Load: func(context.Context) (*domain.Summary, error) { return summary, nil },
+		})
+	}
+	return samples
+}
+
+// Server renders the samples with the current templates. Pages reload themselves in the
+// browser when the templates are reloaded
+type Server struct {
+	config    Config
+	templates *email.TemplateStore
+	samples   []Sample
+	logger    *slog.Logger
+	mux       *http.ServeMux
+}
+
+// NewServer creates a preview server over a template store
+func NewServer(config Config, templates *email.TemplateStore, samples []Sample, logger *slog.Logger) *Server {
+	s := &Server{
+		config:    config,
+		templates: templates,
+		samples:   samples,
+		logger:    logger,
+		mux:       http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /preview/{sample}", s.handlePreview)
+	s.mux.HandleFunc("GET /version", s.handleVersion)
+	return s
+}
+
+// Handler returns the preview handler
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Run serves the previews on the configured address until ctx is canceled
+func (s *Server) Run(ctx context.Context) error {
+	addr := s.config.Addr
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	server := &http.Server{
+		Addr:              net.JoinHostPort(addr, s.config.Port),
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("preview server listening", "url", "http://"+server.Addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("serving previews: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("shutting down preview server: %w", err)
+		}
+		return ctx.Err()
+	}
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Email previews</title>
+<style>body{font-family:sans-serif;margin:2em}td,th{padding:.3em 1em;text-align:left}</style>
+</head>
+<body>
+<h1>Email previews</h1>
+<table>
+<tr><th>Sample</th>{{range .Locales}}<th>{{.}}</th>{{end}}</tr>
+{{range $sample := .Samples}}<tr><td>{{$sample.Name}}</td>{{range $.Locales}}<td><a href="/preview/{{$sample.Name}}?locale={{.}}">plain</a> · <a href="/preview/{{$sample.Name}}?locale={{.}}&amp;statistics=true">statistics</a></td>{{end}}</tr>
+{{end}}</table>
+{{.Reload}}
+</body>
+</html>
+`))
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	version, _ := s.templates.Version()
+	data := struct {
+		Samples []Sample
+		Locales []i18n.Locale
+		Reload  template.HTML
+	}{s.samples, i18n.Supported(), reloadScript(version)}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexTemplate.Execute(w, data); err != nil {
+		s.logger.Error("failed to render preview index", "error", err)
+	}
+}
+
+// handlePreview renders a sample:
+// GET /preview/{sample}[?locale=en|es|pt][&statistics=true]
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	version, reloadErr := s.templates.Version()
+
+	var sample *Sample
+	for i := range s.samples {
+		if s.samples[i].Name == r.PathValue("sample") {
+			sample = &s.samples[i]
+		}
+	}
+	if sample == nil {
+		s.writeError(w, http.StatusNotFound, version, fmt.Errorf("unknown sample %q", r.PathValue("sample")))
+		return
+	}
+
+	options := email.RenderOptions{Locale: i18n.DefaultLocale, Statistics: r.URL.Query().Get("statistics") == "true"}
+	if value := r.URL.Query().Get("locale"); value != "" {
+		locale, err := i18n.Parse(value)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, version, err)
+			return
+		}
+		options.Locale = locale
+	}
+
+	summary, err := sample.Load(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, version, fmt.Errorf("loading sample %s: %w", sample.Name, err))
+		return
+	}
+	html, err := s.templates.Render(summary, options)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, version, err)
+		return
+	}
+
+	// The last good templates are shown after a failed reload; say so on top of them
+	if reloadErr != nil {
+		html = prependBody(html, errorBanner(reloadErr))
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, injectReload(html, version))
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	version, _ := s.templates.Version()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, version)
+}
+
+// writeError writes an error page that still reloads with the templates
+func (s *Server) writeError(w http.ResponseWriter, status int, version uint64, err error) {
+	if status >= http.StatusInternalServerError {
+		s.logger.Error("failed to render preview", "error", err)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprint(w, injectReload("<!DOCTYPE html><html><body>"+errorBanner(err)+"</body></html>", version))
+}
+
+func errorBanner(err error) string {
+	return `<pre style="background:#fdd;color:#900;padding:1em;white-space:pre-wrap">` + template.HTMLEscapeString(err.Error()) + "</pre>"
+}
+
+// prependBody inserts content at the start of the body of a page
+func prependBody(page, content string) string {
+	start := strings.Index(strings.ToLower(page), "<body")
+	if start < 0 {
+		return content + page
+	}
+	end := strings.Index(page[start:], ">")
+	if end < 0 {
+		return content + page
+	}
+	end += start + 1
+	return page[:end] + content + page[end:]
+}
+
+// reloadScript polls the template version and reloads the page once it changes
+func reloadScript(version uint64) template.HTML {
+	return template.HTML(`<script>
+(function() {
+  var version = "` + strconv.FormatUint(version, 10) + `";
+  setInterval(function() {
+    fetch("/version", {cache: "no-store"}).then(function(r) { return r.text(); }).then(function(v) {
+      if (v !== version) { location.reload(); }
+    }).catch(function() {});
+  }, 1000);
+})();
+</script>`)
+}
+
+// injectReload adds the reload script at the end of the body of a page
+func injectReload(page string, version uint64) string {
+	script := string(reloadScript(version))
+	if i := strings.LastIndex(strings.ToLower(page), "</body>"); i >= 0 {
+		return page[:i] + script + page[i:]
+	}
+	return page + script
+}
@@ -0,0 +1,60 @@
+package preview
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NahuelDT/stori-challenge/internal/infrastructure/email"
+)
+
+func TestPreview(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+	store, err := email.NewTemplateStore(dir, logger)
+	if err != nil {
+		t.Fatalf("NewTemplateStore() error = %v", err)
+	}
+	handler := NewServer(Config{}, store, FixtureSamples(), logger).Handler()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	response := get("/preview/credits-only?locale=es&statistics=true")
+	if response.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", response.Code, response.Body)
+	}
+	body := response.Body.String()
+	if !strings.Contains(body, "Tu resumen mensual de Stori") || !strings.Contains(body, `fetch("/version"`) {
+		t.Errorf("preview is not the Spanish email with the reload script")
+	}
+	if got := get("/preview/unknown").Code; got != http.StatusNotFound {
+		t.Errorf("unknown sample: status %d, want 404", got)
+	}
+	if got := get("/preview/empty?locale=xx").Code; got != http.StatusBadRequest {
+		t.Errorf("unknown locale: status %d, want 400", got)
+	}
+
+	// A failed reload bumps the version and is shown over the last good templates
+	if err := os.WriteFile(filepath.Join(dir, "summary.html"), []byte("{{define"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err == nil {
+		t.Fatal("Reload() of a broken template succeeded")
+	}
+	if got := get("/version").Body.String(); got != "2" {
+		t.Errorf("version = %q, want 2", got)
+	}
+	body = get("/preview/empty").Body.String()
+	if !strings.Contains(body, "unclosed action") || !strings.Contains(body, "Your Monthly Stori Snapshot") {
+		t.Errorf("preview after a failed reload does not show the error over the last templates")
+	}
+}
@@ -2,14 +2,16 @@ package services
 
 import "github.com/NahuelDT/stori-challenge/internal/domain"
 
-type summaryCalculator struct{}
+type summaryCalculator struct {
+	options domain.SummaryOptions
+}
 
 // NewSummaryCalculator creates a new summary calculator
-func NewSummaryCalculator() SummaryCalculator {
-	return &summaryCalculator{}
+func NewSummaryCalculator(options domain.SummaryOptions) SummaryCalculator {
+	return &summaryCalculator{options: options}
 }
 
 // Calculate computes summary statistics from transactions
 func (c *summaryCalculator) Calculate(transactions []domain.Transaction) *domain.Summary {
-	return domain.NewSummary(transactions)
+	return domain.NewSummaryWithOptions(transactions, c.options)
 }
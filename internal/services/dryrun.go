@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+// DryRunUnit is the outcome of a dry run over one unit of a file
+type DryRunUnit struct {
+	Name    string                  `json:"name"`
+	Summary *domain.Summary         `json:"summary,omitempty"`
+	Report  domain.ValidationReport `json:"report"`
+	// HTML is the summary email as it would be sent. Its account balance and comparison
+	// with the previous period are only shown when the dry runner reads the database
+	HTML string `json:"html,omitempty"`
+	// Error is set when the unit could not be read or rendered
+	Error string `json:"error,omitempty"`
+}
+
+// DryRunResult is the outcome of a dry run over a file
+type DryRunResult struct {
+	File      string `json:"file"`
+	Recipient string `json:"recipient"`
+	// Valid is false when a unit failed or its report holds errors
+	Valid bool         `json:"valid"`
+	Units []DryRunUnit `json:"units"`
+}
+
+// DryRunner processes files like TransactionProcessor without side effects: transactions
+// are summarized and the email is rendered, but nothing is saved or sent
+type DryRunner struct {
+	fileProcessor FileProcessor
+	calculator    SummaryCalculator
+	renderer      SummaryRenderer
+	dataStore     DataStore
+	logger        *slog.Logger
+}
+
+// NewDryRunner creates a dry runner. With a data store, which is only read, summaries
+// hold the account history the sent email would; without one, it is left out
+func NewDryRunner(fileProcessor FileProcessor, calculator SummaryCalculator, renderer SummaryRenderer, dataStore DataStore, logger *slog.Logger) *DryRunner {
+	return &DryRunner{
+		fileProcessor: fileProcessor,
+		calculator:    calculator,
+		renderer:      renderer,
+		dataStore:     dataStore,
+		logger:        logger,
+	}
+}
+
+// Run reads, validates and summarizes every unit of a file and renders the email the
+// recipient would receive for it. Only a file that cannot be opened fails the run;
+// failures of its units are reported in the result
+func (d *DryRunner) Run(ctx context.Context, filePath, recipient string) (*DryRunResult, error) {
+	d.logger.Info("dry run", "file", filePath, "recipient", recipient)
+
+	units, err := d.fileProcessor.ProcessUnits(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("processing file %s: %w", filePath, err)
+	}
+
+	result := &DryRunResult{File: filePath, Recipient: recipient, Valid: true}
+	now := time.Now()
+	for _, unit := range units {
+		outcome := DryRunUnit{Name: unit.Name}
+		switch {
+		case unit.Err != nil:
+			outcome.Error = unit.Err.Error()
+			outcome.Report = domain.ValidationReport{Issues: []domain.ValidationIssue{
+				{Severity: domain.SeverityError, Message: unit.Err.Error()},
+			}}
+		default:
+			outcome.Report = domain.ValidateTransactions(unit.Transactions, now)
+			if len(unit.Transactions) > 0 {
+				outcome.Summary = d.calculator.Calculate(unit.Transactions)
+				if d.dataStore != nil {
+					if err := previewAccountHistory(ctx, d.dataStore, recipient, outcome.Summary); err != nil {
+						d.logger.Warn("dry run without account history", "file", unit.Name, "error", err)
+					}
+				}
+				html, err := d.renderer.RenderTemplate(recipient, outcome.Summary)
+				if err != nil {
+					outcome.Error = fmt.Sprintf("rendering email: %v", err)
+				}
+				outcome.HTML = html
+			}
+		}
+
+		if outcome.Error != "" || !outcome.Report.Valid() {
+			result.Valid = false
+		}
+		d.logger.Info("dry run unit completed",
+			"file", unit.Name,
+			"transactions", outcome.Report.Transactions,
+			"issues", len(outcome.Report.Issues),
+			"valid", outcome.Error == "" && outcome.Report.Valid())
+		result.Units = append(result.Units, outcome)
+	}
+	return result, nil
+}
+
+// previewAccountHistory completes a summary like addAccountHistory does once its
+// transactions are saved, reading the data store only: the account balance adds the
+// summarized transactions to the saved ones, as if none of them had been saved yet
+func previewAccountHistory(ctx context.Context, dataStore DataStore, email string, summary *domain.Summary) error {
+	account, err := dataStore.GetAccountByEmail(ctx, email)
+	if err != nil && !errors.Is(err, domain.ErrAccountNotFound) {
+		return fmt.Errorf("loading account: %w", err)
+	}
+
+	// A new account holds only the summarized transactions
+	balance := summary.TotalBalance
+	var history []domain.Transaction
+	if err == nil {
+		saved, err := dataStore.GetAccountBalance(ctx, account.ID)
+		if err != nil {
+			return fmt.Errorf("loading account balance: %w", err)
+		}
+		balance = balance.Add(saved)
+	}
+	summary.AccountBalance = &balance
+
+	period, ok := summary.Period()
+	if !ok {
+		return nil
+	}
+	if account.ID != "" {
+		previous := period.Previous()
+		if history, err = dataStore.GetTransactionsByDateRange(ctx, account.ID, previous.From.Start(), previous.To.End()); err != nil {
+			return fmt.Errorf("loading previous period: %w", err)
+		}
+	}
+	summary.Comparison = domain.NewPeriodComparison(summary, history)
+	return nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func TestPreviewAccountHistory(t *testing.T) {
+	store := newMemoryStore()
+	store.accounts = []domain.Account{{ID: "alice", Email: "alice@example.com"}}
+	june := transaction(1, "100")
+	june.Date = time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	store.transactions["alice"] = []domain.Transaction{june}
+
+	calculator := NewSummaryCalculator(domain.SummaryOptions{})
+	ctx := context.Background()
+
+	summary := calculator.Calculate([]domain.Transaction{transaction(1, "10"), transaction(2, "5")})
+	if err := previewAccountHistory(ctx, store, "alice@example.com", summary); err != nil {
+		t.Fatalf("previewAccountHistory: %v", err)
+	}
+	if summary.AccountBalance == nil || !summary.AccountBalance.Equal(decimal.NewFromInt(115)) {
+		t.Errorf("account balance = %v, want 115 with the saved transactions", summary.AccountBalance)
+	}
+	if summary.Comparison == nil {
+		t.Error("no comparison with the previous period")
+	}
+	if len(store.transactions["alice"]) != 1 {
+		t.Errorf("saved %d transactions, want the store left untouched", len(store.transactions["alice"]))
+	}
+
+	// A recipient without an account only has the file's transactions
+	summary = calculator.Calculate([]domain.Transaction{transaction(1, "10")})
+	if err := previewAccountHistory(ctx, store, "bob@example.com", summary); err != nil {
+		t.Fatalf("previewAccountHistory: %v", err)
+	}
+	if summary.AccountBalance == nil || !summary.AccountBalance.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("new account balance = %v, want 10", summary.AccountBalance)
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// AccountPeriod holds the persisted transactions of an account over a period
+type AccountPeriod struct {
+	Account domain.Account
+	Period  domain.Period
+	// OpeningBalance is the account balance before the first day of the period
+	OpeningBalance decimal.Decimal
+	// Transactions are ordered by date
+	Transactions []domain.Transaction
+}
+
+// LoadAccountPeriod loads the transactions of the account with the given email over a period
+func LoadAccountPeriod(ctx context.Context, dataStore DataStore, email string, period domain.Period) (*AccountPeriod, error) {
+	account, err := FindAccount(ctx, dataStore, email)
+	if err != nil {
+		return nil, err
+	}
+
+	opening, err := dataStore.GetAccountBalanceBefore(ctx, account.ID, period.From.Start())
+	if err != nil {
+		return nil, fmt.Errorf("loading balance before %s: %w", period.From, err)
+	}
+
+	transactions, err := dataStore.GetTransactionsByDateRange(ctx, account.ID, period.From.Start(), period.To.End())
+	if err != nil {
+		return nil, fmt.Errorf("loading transactions from %s to %s: %w", period.From, period.To, err)
+	}
+	return &AccountPeriod{
+		Account:        account,
+		Period:         period,
+		OpeningBalance: opening,
+		Transactions:   transactions,
+	}, nil
+}
+
+// FindAccount returns the persisted account with the given email, ErrAccountNotFound when there is none
+func FindAccount(ctx context.Context, dataStore DataStore, email string) (domain.Account, error) {
+	account, err := dataStore.GetAccountByEmail(ctx, email)
+	if err != nil && !errors.Is(err, domain.ErrAccountNotFound) {
+		return domain.Account{}, fmt.Errorf("finding account %s: %w", email, err)
+	}
+	return account, err
+}
+
+// StreamAccountTransactions passes the transactions of an account to fn in date order,
+// those of the period only when it is not nil
+func StreamAccountTransactions(ctx context.Context, dataStore DataStore, accountID string, period *domain.Period, fn func(domain.Transaction) error) error {
+	var start, end time.Time
+	if period != nil {
+		start, end = period.From.Start(), period.To.End()
+	}
+	if err := dataStore.StreamTransactions(ctx, accountID, start, end, fn); err != nil {
+		return fmt.Errorf("exporting transactions of account %s: %w", accountID, err)
+	}
+	return nil
+}
+
+// ParseExportPeriod parses the optional first and last months (YYYY-MM) of an export. It
+// returns nil when both are empty; a single month selects that month alone
+func ParseExportPeriod(from, to string) (*domain.Period, error) {
+	if from == "" && to == "" {
+		return nil, nil
+	}
+	if from == "" {
+		from = to
+	}
+	if to == "" {
+		to = from
+	}
+
+	start, err := domain.ParseYearMonth(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from month: %w", err)
+	}
+	end, err := domain.ParseYearMonth(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to month: %w", err)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("period ends (%s) before it starts (%s)", end, start)
+	}
+	return &domain.Period{From: start, To: end}, nil
+}
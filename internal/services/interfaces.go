@@ -2,28 +2,86 @@ package services
 
 import (
 	"context"
+	"time"
 
-	"github.com/shopspring/decimal"
 	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
 )
 
+// FileUnit is an independently processed part of an input file: the file itself, or one
+// member of an archive
+type FileUnit struct {
+	// Name identifies the unit, e.g. "export.zip:july.csv" for an archive member
+	Name         string
+	Transactions []domain.Transaction
+	// Err is set when the unit could not be read
+	Err error
+}
+
 // FileProcessor handles file processing operations
 type FileProcessor interface {
 	ProcessFile(ctx context.Context, filePath string) ([]domain.Transaction, error)
+	ProcessUnits(ctx context.Context, filePath string) ([]FileUnit, error)
 	WatchDirectory(ctx context.Context, dirPath string) (<-chan string, error)
+	// MoveFile moves a file into a directory under a unique name and returns its new path
+	MoveFile(filePath, directory string) (string, error)
 }
 
 // EmailService handles email operations
 type EmailService interface {
-	SendSummary(ctx context.Context, recipient string, summary *domain.Summary) error
-	RenderTemplate(summary *domain.Summary) (string, error)
+	SendSummary(ctx context.Context, recipient string, summary *domain.Summary, transactions []domain.Transaction) error
+	RenderTemplate(recipient string, summary *domain.Summary) (string, error)
+}
+
+// SummaryRenderer renders the summary email a recipient would receive
+type SummaryRenderer interface {
+	RenderTemplate(recipient string, summary *domain.Summary) (string, error)
+}
+
+// Notification is the payload delivered to notification channels
+type Notification struct {
+	Recipient    string
+	Source       string
+	Summary      *domain.Summary
+	Transactions []domain.Transaction
+}
+
+// Notifier delivers processed summaries to an output channel
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, notification Notification) error
 }
 
 // DataStore handles data persistence operations
 type DataStore interface {
-	SaveTransactions(ctx context.Context, transactions []domain.Transaction) error
+	SaveTransactions(ctx context.Context, accountID string, transactions []domain.Transaction) error
 	GetAccountBalance(ctx context.Context, accountID string) (decimal.Decimal, error)
+	GetAccountBalanceBefore(ctx context.Context, accountID string, date time.Time) (decimal.Decimal, error)
+	GetTransactionsByDateRange(ctx context.Context, accountID string, startDate, endDate time.Time) ([]domain.Transaction, error)
+	// StreamTransactions passes transactions to fn in date order; zero dates select every transaction
+	StreamTransactions(ctx context.Context, accountID string, startDate, endDate time.Time, fn func(domain.Transaction) error) error
+	// GetTransactionsByKeys returns the account's transactions identified by each ID and the
+	// reference at the same index, as IDs alone are not unique. Without references, as for
+	// runs recorded before they were kept, transactions are matched by ID
+	GetTransactionsByKeys(ctx context.Context, accountID string, ids []int, references []string) ([]domain.Transaction, error)
 	SaveAccount(ctx context.Context, email string) (string, error)
+	// GetAccountByEmail compares emails without case and fails with domain.ErrAccountNotFound
+	GetAccountByEmail(ctx context.Context, email string) (domain.Account, error)
+	ListAccounts(ctx context.Context) ([]domain.Account, error)
+
+	// Processing runs record every processed file unit; the audit trail every administrative action
+	SaveProcessingRun(ctx context.Context, run domain.ProcessingRun) (string, error)
+	GetProcessingRun(ctx context.Context, runID string) (domain.ProcessingRun, error)
+	GetQuarantinedRun(ctx context.Context, quarantinePath string) (domain.ProcessingRun, error)
+	ListProcessingRuns(ctx context.Context, limit int) ([]domain.ProcessingRun, error)
+	SaveAuditEntry(ctx context.Context, entry domain.AuditEntry) error
+	ListAuditEntries(ctx context.Context, limit int) ([]domain.AuditEntry, error)
+
+	// Statement runs guarantee each monthly statement is sent once per account; a claim
+	// older than staleAfter was abandoned by a stopped sender and can be claimed again
+	ClaimStatement(ctx context.Context, accountID string, period domain.YearMonth, staleAfter time.Duration) (bool, error)
+	CompleteStatement(ctx context.Context, accountID string, period domain.YearMonth) error
+	ReleaseStatement(ctx context.Context, accountID string, period domain.YearMonth) error
 }
 
 // SummaryCalculator handles summary calculation operations
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// PartialDeliveryError is returned by a fan-out notifier when some channels failed but at
+// least one delivered the notification, which must then not be sent again
+type PartialDeliveryError struct {
+	// Failed names the channels that failed
+	Failed []string
+	err    error
+}
+
+func (e *PartialDeliveryError) Error() string { return e.err.Error() }
+
+func (e *PartialDeliveryError) Unwrap() error { return e.err }
+
+type fanOutNotifier struct {
+	notifiers []Notifier
+	logger    *slog.Logger
+}
+
+// NewFanOutNotifier creates a notifier that delivers every notification to all the given notifiers.
+// Every channel is attempted even if a previous one fails
+func NewFanOutNotifier(notifiers []Notifier, logger *slog.Logger) Notifier {
+	return &fanOutNotifier{
+		notifiers: notifiers,
+		logger:    logger,
+	}
+}
+
+// Name returns the notifier name
+func (n *fanOutNotifier) Name() string {
+	return "fan-out"
+}
+
+// Notify delivers the notification to every configured channel. When only some of them
+// fail, the error is a *PartialDeliveryError
+func (n *fanOutNotifier) Notify(ctx context.Context, notification Notification) error {
+	var (
+		errs   []error
+		failed []string
+	)
+
+	for _, notifier := range n.notifiers {
+		if err := notifier.Notify(ctx, notification); err != nil {
+			n.logger.Error("notification channel failed", "channel", notifier.Name(), "error", err, "recipient", notification.Recipient)
+			errs = append(errs, fmt.Errorf("%s: %w", notifier.Name(), err))
+			failed = append(failed, notifier.Name())
+			continue
+		}
+		n.logger.Debug("notification delivered", "channel", notifier.Name(), "recipient", notification.Recipient)
+	}
+
+	err := errors.Join(errs...)
+	if err != nil && len(failed) < len(n.notifiers) {
+		return &PartialDeliveryError{Failed: failed, err: err}
+	}
+	return err
+}
+
+// Close closes every notifier that holds resources
+func (n *fanOutNotifier) Close() error {
+	var errs []error
+	for _, notifier := range n.notifiers {
+		if closer, ok := notifier.(interface{ Close() error }); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", notifier.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+)
+
+func TestFanOutNotifier(t *testing.T) {
+	errEmail := errors.New("smtp unavailable")
+	errWebhook := errors.New("webhook unavailable")
+
+	tests := []struct {
+		name string
+		// errs fails the email, webhook and file channels, in that order, when set
+		errs        []error
+		wantErrs    []error
+		wantPartial []string
+	}{
+		{name: "all delivered", errs: []error{nil, nil, nil}},
+		{name: "one fails", errs: []error{nil, errWebhook, nil}, wantErrs: []error{errWebhook}, wantPartial: []string{"webhook"}},
+		{name: "first fails", errs: []error{errEmail, nil, nil}, wantErrs: []error{errEmail}, wantPartial: []string{"email"}},
+		{name: "all fail", errs: []error{errEmail, errWebhook, errWebhook}, wantErrs: []error{errEmail, errWebhook}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels := []*recordingNotifier{
+				{name: "email", err: tt.errs[0]},
+				{name: "webhook", err: tt.errs[1]},
+				{name: "file", err: tt.errs[2]},
+			}
+			notifiers := make([]Notifier, len(channels))
+			for i, channel := range channels {
+				notifiers[i] = channel
+			}
+
+			notifier := NewFanOutNotifier(notifiers, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			err := notifier.Notify(context.Background(), Notification{Recipient: "alice@example.com"})
+
+			// Every channel is attempted, whichever failed before it
+			for i, channel := range channels {
+				if delivered := len(channel.notifications) == 1; delivered != (tt.errs[i] == nil) {
+					t.Errorf("%s delivered %d notifications", channel.name, len(channel.notifications))
+				}
+			}
+
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("error = %v, want it to join %v", err, want)
+				}
+			}
+
+			var partial *PartialDeliveryError
+			if isPartial := errors.As(err, &partial); isPartial != (tt.wantPartial != nil) {
+				t.Fatalf("partial delivery = %v, want %v", isPartial, tt.wantPartial != nil)
+			}
+			if partial != nil && !slices.Equal(partial.Failed, tt.wantPartial) {
+				t.Errorf("failed channels = %v, want %v", partial.Failed, tt.wantPartial)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+// errNoDatabase is returned by operations that need persisted runs or transactions
+var errNoDatabase = errors.New("operation requires the database")
+
+// Resend summarizes the transactions of a past processing run again and notifies its
+// recipient, rendering with the current templates. The action is recorded in the audit trail
+func (p *TransactionProcessor) Resend(ctx context.Context, runID, actor string) error {
+	return p.audit(ctx, domain.AuditResend, actor, runID, p.resend(ctx, runID))
+}
+
+func (p *TransactionProcessor) resend(ctx context.Context, runID string) error {
+	if p.dataStore == nil {
+		return errNoDatabase
+	}
+
+	run, err := p.dataStore.GetProcessingRun(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if len(run.TransactionIDs) == 0 {
+		return fmt.Errorf("run %s has no transactions to resend (status %s)", runID, run.Status)
+	}
+	// Transaction IDs only identify transactions within an account, so a run whose
+	// transactions were not persisted has nothing to resend
+	if run.AccountID == "" {
+		return fmt.Errorf("transactions of run %s were not persisted", runID)
+	}
+
+	transactions, err := p.dataStore.GetTransactionsByKeys(ctx, run.AccountID, run.TransactionIDs, run.TransactionReferences)
+	if err != nil {
+		return fmt.Errorf("loading transactions of run %s: %w", runID, err)
+	}
+	if len(transactions) == 0 {
+		return fmt.Errorf("transactions of run %s were not persisted", runID)
+	}
+
+	summary := p.calculator.Calculate(transactions)
+	if err := addAccountHistory(ctx, p.dataStore, run.AccountID, summary); err != nil {
+		p.logger.Warn("summary resent without account history", "run_id", runID, "error", err)
+	}
+
+	notification := Notification{
+		Recipient:    run.Recipient,
+		Source:       run.Source,
+		Summary:      summary,
+		Transactions: transactions,
+	}
+	if err := p.notifier.Notify(ctx, notification); err != nil {
+		return fmt.Errorf("notifying %s: %w", run.Recipient, err)
+	}
+
+	p.logger.Info("summary resent", "run_id", runID, "recipient", run.Recipient, "transactions", len(transactions))
+	return nil
+}
+
+// Recompute builds the summary of an account over a period from its stored transactions
+// and notifies the account. The action is recorded in the audit trail
+func (p *TransactionProcessor) Recompute(ctx context.Context, email string, period domain.Period, actor string) error {
+	target := fmt.Sprintf("%s %s..%s", email, period.From, period.To)
+	return p.audit(ctx, domain.AuditRecompute, actor, target, p.recompute(ctx, email, period))
+}
+
+func (p *TransactionProcessor) recompute(ctx context.Context, email string, period domain.Period) error {
+	if p.dataStore == nil {
+		return errNoDatabase
+	}
+
+	data, err := LoadAccountPeriod(ctx, p.dataStore, email, period)
+	if err != nil {
+		return err
+	}
+	if len(data.Transactions) == 0 {
+		return fmt.Errorf("no transactions for %s from %s to %s", email, period.From, period.To)
+	}
+
+	summary := p.calculator.Calculate(data.Transactions)
+	if err := addAccountHistory(ctx, p.dataStore, data.Account.ID, summary); err != nil {
+		p.logger.Warn("summary recomputed without account history", "account_id", data.Account.ID, "error", err)
+	}
+
+	notification := Notification{
+		Recipient:    data.Account.Email,
+		Source:       fmt.Sprintf("recompute:%s..%s", period.From, period.To),
+		Summary:      summary,
+		Transactions: data.Transactions,
+	}
+	if err := p.notifier.Notify(ctx, notification); err != nil {
+		return fmt.Errorf("notifying %s: %w", data.Account.Email, err)
+	}
+
+	p.logger.Info("summary recomputed", "account_id", data.Account.ID, "from", period.From, "to", period.To, "transactions", len(data.Transactions))
+	return nil
+}
+
+// Reprocess processes a quarantined file again for a recipient. Of an archive, only the
+// members that failed are read again, as the others were already sent. Once it succeeds,
+// the file is moved to the processed directory. The action is recorded in the audit trail
+func (p *TransactionProcessor) Reprocess(ctx context.Context, filePath, recipientEmail, actor string) error {
+	return p.audit(ctx, domain.AuditReprocess, actor, filePath, p.reprocess(ctx, filePath, recipientEmail))
+}
+
+func (p *TransactionProcessor) reprocess(ctx context.Context, filePath, recipientEmail string) error {
+	// Reprocessing must be audited, and its transactions persisted for later resends
+	if p.dataStore == nil {
+		return errNoDatabase
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrFileNotFound, filePath)
+	}
+
+	// A file moved to quarantine by hand has no run, and is processed whole
+	var members []string
+	run, err := p.dataStore.GetQuarantinedRun(ctx, filePath)
+	switch {
+	case err == nil:
+		members = run.FailedMembers
+	case !errors.Is(err, domain.ErrRunNotFound):
+		return fmt.Errorf("loading quarantine of %s: %w", filePath, err)
+	}
+
+	// A file that still fails stays where it is rather than being quarantined again
+	if err := p.processFile(ctx, filePath, recipientEmail, false, members); err != nil {
+		return err
+	}
+
+	if p.directories.Processed != "" {
+		target, err := p.fileProcessor.MoveFile(filePath, p.directories.Processed)
+		if err != nil {
+			return fmt.Errorf("file reprocessed but not moved out of quarantine: %w", err)
+		}
+		p.logger.Info("reprocessed file moved", "file", filePath, "processed_path", target)
+	}
+	return nil
+}
+
+// audit records the outcome of an administrative action and returns err, joined with the
+// failure to record it if any
+func (p *TransactionProcessor) audit(ctx context.Context, action, actor, target string, err error) error {
+	entry := domain.AuditEntry{Action: action, Actor: actor, Target: target}
+	if err != nil {
+		entry.Error = err.Error()
+		p.logger.Error("administrative action failed", "action", action, "actor", actor, "target", target, "error", err)
+	} else {
+		p.logger.Info("administrative action succeeded", "action", action, "actor", actor, "target", target)
+	}
+
+	// Every action fails without the database, so there is nothing to record
+	if p.dataStore == nil {
+		return err
+	}
+	if auditErr := p.dataStore.SaveAuditEntry(ctx, entry); auditErr != nil {
+		return errors.Join(err, fmt.Errorf("recording %s in the audit trail: %w", action, auditErr))
+	}
+	return err
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// memoryStore is a data store holding accounts, processing runs, statement claims and the
+// audit trail in memory
+type memoryStore struct {
+	DataStore
+	accounts     []domain.Account
+	transactions map[string][]domain.Transaction
+	runs         map[string]domain.ProcessingRun
+	statements   map[string]statementRun
+	audit        []domain.AuditEntry
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		transactions: map[string][]domain.Transaction{},
+		runs:         map[string]domain.ProcessingRun{},
+		statements:   map[string]statementRun{},
+	}
+}
+
+func (m *memoryStore) ListAccounts(ctx context.Context) ([]domain.Account, error) {
+	return m.accounts, nil
+}
+
+func (m *memoryStore) GetAccountByEmail(ctx context.Context, email string) (domain.Account, error) {
+	for _, account := range m.accounts {
+		if account.Email == email {
+			return account, nil
+		}
+	}
+	return domain.Account{}, domain.ErrAccountNotFound
+}
+
+func (m *memoryStore) SaveAccount(ctx context.Context, email string) (string, error) {
+	return "account-" + email, nil
+}
+
+func (m *memoryStore) SaveTransactions(ctx context.Context, accountID string, transactions []domain.Transaction) error {
+	m.transactions[accountID] = append(m.transactions[accountID], transactions...)
+	return nil
+}
+
+func (m *memoryStore) GetAccountBalance(ctx context.Context, accountID string) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	for _, transaction := range m.transactions[accountID] {
+		balance = balance.Add(transaction.SignedAmount())
+	}
+	return balance, nil
+}
+
+func (m *memoryStore) GetTransactionsByDateRange(ctx context.Context, accountID string, startDate, endDate time.Time) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	for _, transaction := range m.transactions[accountID] {
+		if !transaction.Date.Before(startDate) && !transaction.Date.After(endDate) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (m *memoryStore) GetTransactionsByKeys(ctx context.Context, accountID string, ids []int, references []string) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	for _, transaction := range m.transactions[accountID] {
+		for i, id := range ids {
+			if transaction.ID == id && (len(references) == 0 || transaction.Reference == references[i]) {
+				transactions = append(transactions, transaction)
+				break
+			}
+		}
+	}
+	return transactions, nil
+}
+
+func (m *memoryStore) SaveProcessingRun(ctx context.Context, run domain.ProcessingRun) (string, error) {
+	run.ID = fmt.Sprintf("run-%d", len(m.runs))
+	m.runs[run.ID] = run
+	return run.ID, nil
+}
+
+func (m *memoryStore) GetProcessingRun(ctx context.Context, runID string) (domain.ProcessingRun, error) {
+	if run, ok := m.runs[runID]; ok {
+		return run, nil
+	}
+	return domain.ProcessingRun{}, fmt.Errorf("%w: %s", domain.ErrRunNotFound, runID)
+}
+
+func (m *memoryStore) GetQuarantinedRun(ctx context.Context, quarantinePath string) (domain.ProcessingRun, error) {
+	for _, run := range m.runs {
+		if run.Status == domain.RunQuarantined && run.QuarantinePath == quarantinePath {
+			return run, nil
+		}
+	}
+	return domain.ProcessingRun{}, domain.ErrRunNotFound
+}
+
+func (m *memoryStore) SaveAuditEntry(ctx context.Context, entry domain.AuditEntry) error {
+	m.audit = append(m.audit, entry)
+	return nil
+}
+
+// recordingNotifier keeps every notification, failing with err when set
+type recordingNotifier struct {
+	name          string
+	notifications []Notification
+	err           error
+}
+
+func (n *recordingNotifier) Name() string {
+	if n.name == "" {
+		return "recording"
+	}
+	return n.name
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification Notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+// stubFiles reads every file as the same units and records the files it moves
+type stubFiles struct {
+	FileProcessor
+	units []FileUnit
+	err   error
+	moved []string
+}
+
+func (f *stubFiles) ProcessUnits(ctx context.Context, filePath string) ([]FileUnit, error) {
+	return f.units, f.err
+}
+
+func (f *stubFiles) MoveFile(filePath, directory string) (string, error) {
+	f.moved = append(f.moved, filePath)
+	return filepath.Join(directory, filepath.Base(filePath)), nil
+}
+
+func newTestProcessor(files FileProcessor, notifier Notifier, store DataStore) *TransactionProcessor {
+	processor := NewTransactionProcessor(files, notifier, NewSummaryCalculator(domain.SummaryOptions{}), store,
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	processor.SetDirectories(FileDirectories{Quarantine: "/quarantine", Processed: "/processed"})
+	return processor
+}
+
+func transaction(id int, amount string) domain.Transaction {
+	return domain.Transaction{
+		ID:     id,
+		Date:   time.Date(2024, 7, id+1, 0, 0, 0, 0, time.UTC),
+		Amount: decimal.RequireFromString(amount),
+		Type:   domain.Credit,
+	}
+}
+
+func TestResend(t *testing.T) {
+	store := newMemoryStore()
+	// Both accounts use ID 1: only the run's account may be summarized
+	store.transactions["alice"] = []domain.Transaction{transaction(1, "10"), transaction(2, "5")}
+	store.transactions["bob"] = []domain.Transaction{transaction(1, "999")}
+	store.runs["sent"] = domain.ProcessingRun{ID: "sent", Recipient: "alice@example.com", AccountID: "alice", Status: domain.RunSent, TransactionIDs: []int{1, 2}}
+	store.runs["unsaved"] = domain.ProcessingRun{ID: "unsaved", Recipient: "alice@example.com", Status: domain.RunSent, TransactionIDs: []int{1}}
+
+	notifier := &recordingNotifier{}
+	processor := newTestProcessor(&stubFiles{}, notifier, store)
+	ctx := context.Background()
+
+	if err := processor.Resend(ctx, "sent", "ops"); err != nil {
+		t.Fatalf("Resend: %v", err)
+	}
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("sent %d notifications, want 1", len(notifier.notifications))
+	}
+	if got := notifier.notifications[0].Summary.TotalBalance; !got.Equal(decimal.NewFromInt(15)) {
+		t.Errorf("resent balance = %s, want 15 from alice's transactions only", got)
+	}
+
+	// A run whose transactions were never persisted has nothing of its own to resend
+	if err := processor.Resend(ctx, "unsaved", "ops"); err == nil {
+		t.Error("Resend of a run without an account succeeded")
+	}
+	if err := processor.Resend(ctx, "missing", "ops"); !errors.Is(err, domain.ErrRunNotFound) {
+		t.Errorf("Resend of a missing run: error = %v, want ErrRunNotFound", err)
+	}
+	if len(notifier.notifications) != 1 {
+		t.Errorf("failed resends sent %d more notifications", len(notifier.notifications)-1)
+	}
+
+	want := []domain.AuditEntry{
+		{Action: domain.AuditResend, Actor: "ops", Target: "sent"},
+		{Action: domain.AuditResend, Actor: "ops", Target: "unsaved"},
+		{Action: domain.AuditResend, Actor: "ops", Target: "missing"},
+	}
+	if len(store.audit) != len(want) {
+		t.Fatalf("audit trail holds %d entries, want %d", len(store.audit), len(want))
+	}
+	for i, entry := range store.audit {
+		if entry.Action != want[i].Action || entry.Actor != want[i].Actor || entry.Target != want[i].Target {
+			t.Errorf("audit entry %d = %+v, want %+v", i, entry, want[i])
+		}
+		if failed := entry.Error != ""; failed != (i > 0) {
+			t.Errorf("audit entry %d error = %q", i, entry.Error)
+		}
+	}
+}
+
+func TestResendRunsSharingIDs(t *testing.T) {
+	// A CSV row and a bank transaction whose numeric reference gives it the same ID
+	csvRow := transaction(1, "10")
+	bankRow := transaction(1, "500")
+	bankRow.Reference = "1"
+
+	store := newMemoryStore()
+	notifier := &recordingNotifier{}
+	processor := newTestProcessor(&stubFiles{}, notifier, store)
+	ctx := context.Background()
+
+	for _, transactions := range [][]domain.Transaction{{csvRow}, {bankRow}} {
+		files := &stubFiles{units: []FileUnit{{Name: "statement", Transactions: transactions}}}
+		processor.fileProcessor = files
+		if err := processor.ProcessTransactionFile(ctx, "statement", "alice@example.com"); err != nil {
+			t.Fatalf("ProcessTransactionFile: %v", err)
+		}
+	}
+	if len(store.runs) != 2 {
+		t.Fatalf("recorded %d runs, want 2", len(store.runs))
+	}
+
+	for runID, run := range store.runs {
+		notifier.notifications = nil
+		if err := processor.Resend(ctx, runID, "ops"); err != nil {
+			t.Fatalf("Resend %s: %v", runID, err)
+		}
+		sent := notifier.notifications[0].Transactions
+		if len(sent) != 1 || sent[0].Reference != run.TransactionReferences[0] {
+			t.Errorf("resent run %s with %+v, want its own transaction only", runID, sent)
+		}
+	}
+}
+
+func TestReprocessFailingFileStaysInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.csv")
+	if err := os.WriteFile(path, []byte("broken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMemoryStore()
+	files := &stubFiles{err: domain.ErrInvalidFileFormat}
+	notifier := &recordingNotifier{}
+	processor := newTestProcessor(files, notifier, store)
+
+	if err := processor.Reprocess(context.Background(), path, "alice@example.com", "ops"); !errors.Is(err, domain.ErrInvalidFileFormat) {
+		t.Fatalf("Reprocess: error = %v, want ErrInvalidFileFormat", err)
+	}
+	if len(files.moved) > 0 {
+		t.Errorf("moved %v, want the file left in place", files.moved)
+	}
+	if len(store.runs) > 0 {
+		t.Errorf("recorded %d runs, want the file not quarantined again", len(store.runs))
+	}
+	if len(notifier.notifications) > 0 {
+		t.Errorf("sent %d notifications", len(notifier.notifications))
+	}
+	if len(store.audit) != 1 || store.audit[0].Action != domain.AuditReprocess || store.audit[0].Error == "" {
+		t.Errorf("audit trail = %+v, want one failed reprocess", store.audit)
+	}
+}
+
+func TestReprocessReadsOnlyFailedMembers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.zip")
+	if err := os.WriteFile(path, []byte("zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := &stubFiles{units: []FileUnit{
+		{Name: path + ":july.csv", Transactions: []domain.Transaction{transaction(1, "10")}},
+		{Name: path + ":august.csv", Err: domain.ErrInvalidFileFormat},
+	}}
+	store := newMemoryStore()
+	notifier := &recordingNotifier{}
+	processor := newTestProcessor(files, notifier, store)
+	ctx := context.Background()
+
+	// The first pass sends july.csv and quarantines the archive for august.csv
+	if err := processor.ProcessTransactionFile(ctx, path, "alice@example.com"); err == nil {
+		t.Fatal("ProcessTransactionFile succeeded with a member that cannot be read")
+	}
+	quarantined, err := store.GetQuarantinedRun(ctx, filepath.Join("/quarantine", "export.zip"))
+	if err != nil {
+		t.Fatalf("no quarantined run: %v", err)
+	}
+	if !slices.Equal(quarantined.FailedMembers, []string{"august.csv"}) {
+		t.Errorf("failed members = %v, want [august.csv]", quarantined.FailedMembers)
+	}
+
+	// Once fixed, reprocessing sends august.csv alone. The stub did not move the archive,
+	// so the quarantine points at where it still is
+	files.units[1] = FileUnit{Name: path + ":august.csv", Transactions: []domain.Transaction{transaction(2, "5")}}
+	store.runs[quarantined.ID] = domain.ProcessingRun{ID: quarantined.ID, Status: domain.RunQuarantined, QuarantinePath: path, FailedMembers: quarantined.FailedMembers}
+	files.moved = nil
+	notifier.notifications = nil
+
+	if err := processor.Reprocess(ctx, path, "alice@example.com", "ops"); err != nil {
+		t.Fatalf("Reprocess: %v", err)
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Source != path+":august.csv" {
+		t.Errorf("notifications = %+v, want august.csv alone", notifier.notifications)
+	}
+	if !slices.Equal(files.moved, []string{path}) {
+		t.Errorf("moved %v, want the archive moved out of quarantine", files.moved)
+	}
+	if len(store.audit) != 1 || store.audit[0].Error != "" {
+		t.Errorf("audit trail = %+v, want one successful reprocess", store.audit)
+	}
+}
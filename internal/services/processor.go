@@ -2,73 +2,256 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
 )
 
 type TransactionProcessor struct {
 	fileProcessor FileProcessor
-	emailService  EmailService
+	notifier      Notifier
 	calculator    SummaryCalculator
 	dataStore     DataStore
+	directories   FileDirectories
 	logger        *slog.Logger
 }
 
+// FileDirectories are where input files are moved after processing
+type FileDirectories struct {
+	// Quarantine receives files that cannot be read; empty leaves them in place
+	Quarantine string
+	// Processed receives quarantined files once reprocessed; empty leaves them in place
+	Processed string
+}
+
 // NewTransactionProcessor creates a new transaction processor
 func NewTransactionProcessor(
 	fileProcessor FileProcessor,
-	emailService EmailService,
+	notifier Notifier,
 	calculator SummaryCalculator,
 	dataStore DataStore,
 	logger *slog.Logger,
 ) *TransactionProcessor {
 	return &TransactionProcessor{
 		fileProcessor: fileProcessor,
-		emailService:  emailService,
+		notifier:      notifier,
 		calculator:    calculator,
 		dataStore:     dataStore,
 		logger:        logger,
 	}
 }
 
-// ProcessTransactionFile processes a single transaction file
+// SetDirectories sets where input files are moved after processing
+func (p *TransactionProcessor) SetDirectories(directories FileDirectories) {
+	p.directories = directories
+}
+
+// ProcessTransactionFile processes a transaction file. Each member of an archive is
+// summarized and notified on its own; the error joins the failures of every member.
+// A file with a unit that cannot be read is moved to the quarantine directory
 func (p *TransactionProcessor) ProcessTransactionFile(ctx context.Context, filePath, recipientEmail string) error {
+	return p.processFile(ctx, filePath, recipientEmail, true, nil)
+}
+
+// processFile processes the units of a file, or only the named members of an archive when
+// members is not empty. With quarantine, a file with a unit that cannot be read is moved aside
+func (p *TransactionProcessor) processFile(ctx context.Context, filePath, recipientEmail string, quarantine bool, members []string) error {
 	p.logger.Info("processing transaction file", "file", filePath, "recipient", recipientEmail)
 
 	// Process the file
-	transactions, err := p.fileProcessor.ProcessFile(ctx, filePath)
+	units, err := p.fileProcessor.ProcessUnits(ctx, filePath)
 	if err != nil {
 		p.logger.Error("failed to process file", "error", err, "file", filePath)
-		return fmt.Errorf("processing file %s: %w", filePath, err)
+		err = fmt.Errorf("processing file %s: %w", filePath, err)
+		if quarantine {
+			p.quarantine(ctx, filePath, recipientEmail, nil, err)
+		}
+		return err
+	}
+	if len(members) > 0 {
+		if units = selectMembers(filePath, units, members); len(units) == 0 {
+			return fmt.Errorf("processing file %s: none of the members %s found", filePath, strings.Join(members, ", "))
+		}
 	}
 
-	if len(transactions) == 0 {
-		p.logger.Warn("no transactions found in file", "file", filePath)
-		return fmt.Errorf("no transactions found in file %s", filePath)
+	var (
+		readErrs, errs []error
+		failed         []string
+	)
+	for _, unit := range units {
+		if unit.Err == nil && len(unit.Transactions) == 0 {
+			p.logger.Warn("no transactions found in file", "file", unit.Name)
+			unit.Err = fmt.Errorf("no transactions found in file %s", unit.Name)
+		}
+		if unit.Err != nil {
+			p.logger.Error("failed to process file", "error", unit.Err, "file", unit.Name)
+			readErrs = append(readErrs, fmt.Errorf("processing file %s: %w", unit.Name, unit.Err))
+			if member, ok := memberName(filePath, unit.Name); ok {
+				failed = append(failed, member)
+			}
+			continue
+		}
+		if err := p.processTransactions(ctx, unit.Name, unit.Transactions, recipientEmail); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	p.logger.Info("transactions processed", "count", len(transactions), "file", filePath)
+	if len(readErrs) > 0 && quarantine {
+		p.quarantine(ctx, filePath, recipientEmail, failed, errors.Join(readErrs...))
+	}
+	return errors.Join(append(readErrs, errs...)...)
+}
+
+// memberName returns the archive member a unit of filePath was read from
+func memberName(filePath, unitName string) (string, bool) {
+	return strings.CutPrefix(unitName, filePath+":")
+}
+
+// selectMembers keeps the units read from the named archive members
+func selectMembers(filePath string, units []FileUnit, members []string) []FileUnit {
+	var selected []FileUnit
+	for _, unit := range units {
+		if member, ok := memberName(filePath, unit.Name); ok && slices.Contains(members, member) {
+			selected = append(selected, unit)
+		}
+	}
+	return selected
+}
+
+// quarantine moves a file that cannot be read out of the watched directory, recording
+// where it went and which of its archive members failed, so that only those are
+// reprocessed: the others were already sent
+func (p *TransactionProcessor) quarantine(ctx context.Context, filePath, recipientEmail string, failedMembers []string, cause error) {
+	if p.directories.Quarantine == "" {
+		return
+	}
+
+	target, err := p.fileProcessor.MoveFile(filePath, p.directories.Quarantine)
+	if err != nil {
+		p.logger.Error("failed to quarantine file", "file", filePath, "error", err)
+		return
+	}
+	p.logger.Warn("file quarantined", "file", filePath, "quarantine_path", target)
+
+	p.recordRun(ctx, domain.ProcessingRun{
+		Source:         filePath,
+		Recipient:      recipientEmail,
+		Status:         domain.RunQuarantined,
+		Error:          cause.Error(),
+		QuarantinePath: target,
+		FailedMembers:  failedMembers,
+	})
+}
+
+// processTransactions summarizes, persists and notifies the transactions read from a source
+func (p *TransactionProcessor) processTransactions(ctx context.Context, source string, transactions []domain.Transaction, recipientEmail string) error {
+	p.logger.Info("transactions processed", "count", len(transactions), "file", source)
 
 	// Calculate summary
 	summary := p.calculator.Calculate(transactions)
 
 	// Save to database if datastore is available
+	var accountID string
 	if p.dataStore != nil {
-		if err := p.dataStore.SaveTransactions(ctx, transactions); err != nil {
+		// Don't fail the entire process if the database is unavailable
+		var err error
+		if accountID, err = p.persist(ctx, recipientEmail, transactions, summary); err != nil {
 			p.logger.Error("failed to save transactions to database", "error", err)
-			// Don't fail the entire process if database save fails
-		} else {
-			p.logger.Info("transactions saved to database", "count", len(transactions))
 		}
 	}
 
-	// Send email summary
-	if err := p.emailService.SendSummary(ctx, recipientEmail, summary); err != nil {
-		p.logger.Error("failed to send email summary", "error", err, "recipient", recipientEmail)
-		return fmt.Errorf("sending email to %s: %w", recipientEmail, err)
+	run := domain.ProcessingRun{
+		Source:    source,
+		Recipient: recipientEmail,
+		AccountID: accountID,
+		Status:    domain.RunSent,
+	}
+	run.TransactionIDs, run.TransactionReferences = transactionKeys(transactions)
+
+	// Notify configured channels
+	notification := Notification{
+		Recipient:    recipientEmail,
+		Source:       source,
+		Summary:      summary,
+		Transactions: transactions,
+	}
+	if err := p.notifier.Notify(ctx, notification); err != nil {
+		p.logger.Error("failed to send summary notification", "error", err, "recipient", recipientEmail)
+		run.Status, run.Error = domain.RunFailed, err.Error()
+		p.recordRun(ctx, run)
+		return fmt.Errorf("notifying %s: %w", recipientEmail, err)
+	}
+	p.recordRun(ctx, run)
+
+	p.logger.Info("file succesfully processed", "file", source, "recipient", recipientEmail)
+	return nil
+}
+
+// recordRun saves a processing run when the database is available
+func (p *TransactionProcessor) recordRun(ctx context.Context, run domain.ProcessingRun) {
+	if p.dataStore == nil {
+		return
+	}
+	runID, err := p.dataStore.SaveProcessingRun(ctx, run)
+	if err != nil {
+		p.logger.Error("failed to record processing run", "file", run.Source, "error", err)
+		return
+	}
+	p.logger.Info("processing run recorded", "run_id", runID, "file", run.Source, "status", run.Status)
+}
+
+// transactionKeys returns the ID and reference identifying each transaction in its account
+func transactionKeys(transactions []domain.Transaction) ([]int, []string) {
+	ids := make([]int, len(transactions))
+	references := make([]string, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+		references[i] = transaction.Reference
+	}
+	return ids, references
+}
+
+// persist saves the transactions under the recipient's account, then completes the
+// summary with the account balance and the comparison with the previous period. It
+// returns the account ID once the transactions are saved
+func (p *TransactionProcessor) persist(ctx context.Context, recipientEmail string, transactions []domain.Transaction, summary *domain.Summary) (string, error) {
+	accountID, err := p.dataStore.SaveAccount(ctx, recipientEmail)
+	if err != nil {
+		return "", fmt.Errorf("resolving account for %s: %w", recipientEmail, err)
+	}
+
+	if err := p.dataStore.SaveTransactions(ctx, accountID, transactions); err != nil {
+		return "", err
+	}
+	p.logger.Info("transactions saved to database", "count", len(transactions), "account_id", accountID)
+
+	return accountID, addAccountHistory(ctx, p.dataStore, accountID, summary)
+}
+
+// addAccountHistory completes a summary with the persisted account balance and the
+// comparison with the account's previous period
+func addAccountHistory(ctx context.Context, dataStore DataStore, accountID string, summary *domain.Summary) error {
+	balance, err := dataStore.GetAccountBalance(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("loading account balance: %w", err)
+	}
+	summary.AccountBalance = &balance
+
+	period, ok := summary.Period()
+	if !ok {
+		return nil
+	}
+	previous := period.Previous()
+	history, err := dataStore.GetTransactionsByDateRange(ctx, accountID, previous.From.Start(), previous.To.End())
+	if err != nil {
+		return fmt.Errorf("loading previous period: %w", err)
 	}
+	summary.Comparison = domain.NewPeriodComparison(summary, history)
 
-	p.logger.Info("file succesfully processed", "recipient", recipientEmail)
 	return nil
 }
 
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+)
+
+// Schedule yields the activation times of a recurring job
+type Schedule interface {
+	// Next returns the first activation after t, or the zero time if there is none
+	Next(t time.Time) time.Time
+}
+
+// DefaultClaimTimeout is how long a statement claim holds before it is considered abandoned
+const DefaultClaimTimeout = time.Hour
+
+// StatementScheduler sends every account a statement of the previous calendar month
+// built from persisted transactions
+type StatementScheduler struct {
+	schedule     Schedule
+	dataStore    DataStore
+	notifier     Notifier
+	calculator   SummaryCalculator
+	claimTimeout time.Duration
+	logger       *slog.Logger
+	now          func() time.Time
+}
+
+// NewStatementScheduler creates a statement scheduler. A nil schedule only allows
+// sending statements on demand with SendStatements
+func NewStatementScheduler(
+	schedule Schedule,
+	dataStore DataStore,
+	notifier Notifier,
+	calculator SummaryCalculator,
+	logger *slog.Logger,
+) *StatementScheduler {
+	return &StatementScheduler{
+		schedule:     schedule,
+		dataStore:    dataStore,
+		notifier:     notifier,
+		calculator:   calculator,
+		claimTimeout: DefaultClaimTimeout,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// SetClaimTimeout sets how long a statement claim holds. A sender stopped between
+// claiming and sending a statement leaves its claim behind; once older than the timeout,
+// the statement is sent by the next run. It must exceed the time a send can take
+func (s *StatementScheduler) SetClaimTimeout(timeout time.Duration) {
+	s.claimTimeout = timeout
+}
+
+// Run sends the statements of the previous month at every scheduled time until ctx is done
+func (s *StatementScheduler) Run(ctx context.Context) error {
+	if s.schedule == nil {
+		return errors.New("no statement schedule configured")
+	}
+
+	for {
+		next := s.schedule.Next(s.now())
+		if next.IsZero() {
+			return errors.New("statement schedule has no upcoming activation")
+		}
+		s.logger.Info("next statement run scheduled", "at", next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		period := domain.NewYearMonth(next).AddMonths(-1)
+		if err := s.SendStatements(ctx, period); err != nil {
+			s.logger.Error("statement run failed", "period", period, "error", err)
+		}
+	}
+}
+
+// SendStatements sends the statement of period to every account that has not received it yet
+func (s *StatementScheduler) SendStatements(ctx context.Context, period domain.YearMonth) error {
+	accounts, err := s.dataStore.ListAccounts(ctx)
+	if err != nil {
+		return fmt.Errorf("listing accounts: %w", err)
+	}
+
+	s.logger.Info("sending monthly statements", "period", period, "accounts", len(accounts))
+
+	var errs []error
+	sent := 0
+	for _, account := range accounts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ok, err := s.sendStatement(ctx, account, period)
+		if err != nil {
+			s.logger.Error("failed to send statement", "account_id", account.ID, "period", period, "error", err)
+			errs = append(errs, fmt.Errorf("account %s: %w", account.ID, err))
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	s.logger.Info("monthly statements finished", "period", period, "sent", sent, "failed", len(errs))
+	return errors.Join(errs...)
+}
+
+// sendStatement claims, builds and delivers one statement. It returns false when there
+// was nothing to send: the period was already claimed or the account had no activity
+func (s *StatementScheduler) sendStatement(ctx context.Context, account domain.Account, period domain.YearMonth) (bool, error) {
+	transactions, err := s.dataStore.GetTransactionsByDateRange(ctx, account.ID, period.Start(), period.End())
+	if err != nil {
+		return false, fmt.Errorf("loading transactions: %w", err)
+	}
+	if len(transactions) == 0 {
+		s.logger.Debug("no activity, skipping statement", "account_id", account.ID, "period", period)
+		return false, nil
+	}
+
+	claimed, err := s.dataStore.ClaimStatement(ctx, account.ID, period, s.claimTimeout)
+	if err != nil {
+		return false, fmt.Errorf("claiming statement: %w", err)
+	}
+	if !claimed {
+		s.logger.Debug("statement already sent or being sent", "account_id", account.ID, "period", period)
+		return false, nil
+	}
+
+	summary := s.calculator.Calculate(transactions)
+	if err := addAccountHistory(ctx, s.dataStore, account.ID, summary); err != nil {
+		s.logger.Warn("statement sent without account history", "account_id", account.ID, "error", err)
+	}
+
+	notification := Notification{
+		Recipient:    account.Email,
+		Source:       "statement:" + period.String(),
+		Summary:      summary,
+		Transactions: transactions,
+	}
+	// Channels that delivered would send again if the claim were released, so a partial
+	// delivery completes it and the failed channels are not retried
+	var partial *PartialDeliveryError
+	if err := s.notifier.Notify(ctx, notification); errors.As(err, &partial) {
+		s.logger.Error("statement not delivered to every channel", "account_id", account.ID, "period", period, "failed", partial.Failed, "error", err)
+	} else if err != nil {
+		if releaseErr := s.dataStore.ReleaseStatement(ctx, account.ID, period); releaseErr != nil {
+			err = errors.Join(err, fmt.Errorf("releasing claim: %w", releaseErr))
+		}
+		return false, fmt.Errorf("notifying %s: %w", account.Email, err)
+	}
+
+	if err := s.dataStore.CompleteStatement(ctx, account.ID, period); err != nil {
+		return true, fmt.Errorf("marking statement as sent: %w", err)
+	}
+
+	s.logger.Info("statement sent", "account_id", account.ID, "recipient", account.Email, "period", period)
+	return true, nil
+}
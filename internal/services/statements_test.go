@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/NahuelDT/stori-challenge/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// statementRun is a statement claim as the statement_runs table holds it
+type statementRun struct {
+	sent      bool
+	claimedAt time.Time
+}
+
+func (m *memoryStore) ClaimStatement(ctx context.Context, accountID string, period domain.YearMonth, staleAfter time.Duration) (bool, error) {
+	key := accountID + "/" + period.String()
+	if run, ok := m.statements[key]; ok && (run.sent || time.Since(run.claimedAt) < staleAfter) {
+		return false, nil
+	}
+	m.statements[key] = statementRun{claimedAt: time.Now()}
+	return true, nil
+}
+
+func (m *memoryStore) CompleteStatement(ctx context.Context, accountID string, period domain.YearMonth) error {
+	key := accountID + "/" + period.String()
+	m.statements[key] = statementRun{sent: true, claimedAt: m.statements[key].claimedAt}
+	return nil
+}
+
+func (m *memoryStore) ReleaseStatement(ctx context.Context, accountID string, period domain.YearMonth) error {
+	delete(m.statements, accountID+"/"+period.String())
+	return nil
+}
+
+func TestSendStatementsClaims(t *testing.T) {
+	store := newMemoryStore()
+	store.accounts = []domain.Account{{ID: "alice", Email: "alice@example.com"}}
+	store.transactions["alice"] = []domain.Transaction{transaction(1, "10")}
+	period := domain.YearMonth{Year: 2024, Month: time.July}
+
+	notifier := &recordingNotifier{err: errors.New("smtp unavailable")}
+	scheduler := NewStatementScheduler(nil, store, notifier, NewSummaryCalculator(domain.SummaryOptions{}),
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	scheduler.SetClaimTimeout(time.Minute)
+	ctx := context.Background()
+
+	// A failed send releases its claim, so the next run retries it
+	if err := scheduler.SendStatements(ctx, period); err == nil {
+		t.Fatal("SendStatements succeeded with a failing notifier")
+	}
+	if len(store.statements) != 0 {
+		t.Fatalf("claims after a failed send = %+v, want released", store.statements)
+	}
+
+	notifier.err = nil
+	if err := scheduler.SendStatements(ctx, period); err != nil {
+		t.Fatalf("SendStatements: %v", err)
+	}
+	if err := scheduler.SendStatements(ctx, period); err != nil {
+		t.Fatalf("SendStatements again: %v", err)
+	}
+	if len(notifier.notifications) != 1 {
+		t.Errorf("sent %d statements, want 1", len(notifier.notifications))
+	}
+	if !store.statements["alice/2024-07"].sent {
+		t.Error("statement not marked as sent")
+	}
+
+	// A claim left by a sender stopped midway holds until it is stale, then is taken over
+	next := period.AddMonths(1)
+	store.transactions["alice"] = append(store.transactions["alice"], domain.Transaction{
+		ID: 2, Date: next.Start(), Amount: decimal.NewFromInt(5), Type: domain.Credit,
+	})
+	store.statements["alice/"+next.String()] = statementRun{claimedAt: time.Now()}
+	if err := scheduler.SendStatements(ctx, next); err != nil {
+		t.Fatalf("SendStatements: %v", err)
+	}
+	if len(notifier.notifications) != 1 {
+		t.Fatal("sent a statement still claimed by another sender")
+	}
+
+	store.statements["alice/"+next.String()] = statementRun{claimedAt: time.Now().Add(-2 * time.Minute)}
+	if err := scheduler.SendStatements(ctx, next); err != nil {
+		t.Fatalf("SendStatements: %v", err)
+	}
+	if len(notifier.notifications) != 2 || !store.statements["alice/"+next.String()].sent {
+		t.Error("stale claim was not taken over")
+	}
+}
+
+func TestSendStatementsPartialDelivery(t *testing.T) {
+	store := newMemoryStore()
+	store.accounts = []domain.Account{{ID: "alice", Email: "alice@example.com"}}
+	store.transactions["alice"] = []domain.Transaction{transaction(1, "10")}
+	period := domain.YearMonth{Year: 2024, Month: time.July}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	delivered := &recordingNotifier{}
+	failing := &recordingNotifier{err: errors.New("webhook unavailable")}
+	notifier := NewFanOutNotifier([]Notifier{delivered, failing}, logger)
+	scheduler := NewStatementScheduler(nil, store, notifier, NewSummaryCalculator(domain.SummaryOptions{}), logger)
+	ctx := context.Background()
+
+	// The channel that delivered must not send the statement again on the next run
+	for range 2 {
+		if err := scheduler.SendStatements(ctx, period); err != nil {
+			t.Fatalf("SendStatements: %v", err)
+		}
+	}
+	if len(delivered.notifications) != 1 {
+		t.Errorf("delivered %d statements, want 1", len(delivered.notifications))
+	}
+	if !store.statements["alice/2024-07"].sent {
+		t.Error("partially delivered statement not marked as sent")
+	}
+}